@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+)
+
+// loggerName lets LOG_LEVELS=audit=debug change the level of the audit sink logs only.
+const loggerName = "audit"
+
+// ErrChainBroken is returned by Verify when a record was altered, removed or reordered.
+var ErrChainBroken = errors.New("audit: hash chain broken")
+
+// ErrSinkClosed is returned by the writes to a sink after Close.
+var ErrSinkClosed = errors.New("audit: sink closed")
+
+// Sink stores encoded audit records, one per call, in the order they are written.
+type Sink interface {
+	Write(ctx context.Context, ev *AuditEvent, record []byte) error
+	Close() error
+}
+
+// chainResumer is implemented by sinks that can return the hash of their last record,
+// so a restarted service continues the chain instead of starting a new one.
+type chainResumer interface {
+	LastHash() (string, error)
+}
+
+type Options struct {
+	// Key signs the chain with HMAC-SHA256; without it records are chained with SHA-256,
+	// which detects edits but not a rewrite of the whole chain.
+	Key []byte
+}
+
+// Auditor writes audit records to a dedicated sink, separate from logger.Zap.
+type Auditor struct {
+	mu       sync.Mutex
+	sink     Sink
+	key      []byte
+	prevHash string
+}
+
+func New(sink Sink, opts Options) (*Auditor, error) {
+	a := &Auditor{sink: sink, key: opts.Key}
+	if r, ok := sink.(chainResumer); ok {
+		last, err := r.LastHash()
+		if err != nil {
+			return nil, err
+		}
+		a.prevHash = last
+	}
+
+	return a, nil
+}
+
+// Record completes ev with an ID, time, trace ID and the chain hashes, then writes it.
+// Records are serialized so the chain matches the order of the sink.
+func (a *Auditor) Record(ctx context.Context, ev AuditEvent) error {
+	if ev.ID == "" {
+		ev.ID = newEventID()
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	if ev.Actor == "" {
+		ev.Actor = ActorFromContext(ctx)
+	}
+	if ev.TraceID == "" {
+		if span := sentry.SpanFromContext(ctx); span != nil {
+			ev.TraceID = span.TraceID.String()
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ev.PrevHash = a.prevHash
+	sum, err := chainHash(a.key, &ev)
+	if err != nil {
+		return err
+	}
+	ev.Hash = sum
+
+	record, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrap(err, "audit: marshal event")
+	}
+	if err := a.sink.Write(ctx, &ev, record); err != nil {
+		return errors.Wrap(err, "audit: write event")
+	}
+	a.prevHash = ev.Hash
+
+	return nil
+}
+
+func (a *Auditor) Close() error {
+	return a.sink.Close()
+}
+
+// Verify reads newline-delimited records, as written by FileSink, and checks that
+// every record hashes to its Hash and links to the previous one. The first record
+// must link to prevHash: empty at the start of a chain, or the hash returned for the
+// previous, rotated file. It returns the hash of the last record.
+func Verify(r io.Reader, key []byte, prevHash string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var ev AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return "", errors.Wrapf(err, "audit: line %d", line)
+		}
+		if ev.PrevHash != prevHash {
+			return "", errors.Wrapf(ErrChainBroken, "line %d: event %s does not follow the previous record", line, ev.ID)
+		}
+		sum, err := chainHash(key, &ev)
+		if err != nil {
+			return "", err
+		}
+		if !hmac.Equal([]byte(sum), []byte(ev.Hash)) {
+			return "", errors.Wrapf(ErrChainBroken, "line %d: event %s was modified", line, ev.ID)
+		}
+		prevHash = ev.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return prevHash, nil
+}
+
+// VerifyFiles verifies the file of a FileSink and its rotated backups, oldest first,
+// as a single chain starting with an empty PrevHash.
+func VerifyFiles(filename string, key []byte) error {
+	ext := filepath.Ext(filename)
+	backups, err := filepath.Glob(strings.TrimSuffix(filename, ext) + "-*" + ext + "*")
+	if err != nil {
+		return errors.Wrap(err, "audit: list rotated files")
+	}
+	// Backups are named <name>-<timestamp><ext>[.gz], so they sort by age.
+	sort.Strings(backups)
+
+	prevHash := ""
+	for _, path := range append(backups, filename) {
+		if prevHash, err = verifyFile(path, key, prevHash); err != nil {
+			return errors.Wrap(err, path)
+		}
+	}
+
+	return nil
+}
+
+func verifyFile(path string, key []byte, prevHash string) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return Verify(r, key, prevHash)
+}
+
+// chainHash hashes the event without its Hash, PrevHash included.
+func chainHash(key []byte, ev *AuditEvent) (string, error) {
+	unsigned := *ev
+	unsigned.Hash = ""
+	b, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", errors.Wrap(err, "audit: marshal event")
+	}
+
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(b)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// memorySink keeps the records the way FileSink writes them, one per line.
+type memorySink struct {
+	buf bytes.Buffer
+}
+
+func (s *memorySink) Write(_ context.Context, _ *AuditEvent, record []byte) error {
+	s.buf.Write(append(record, '\n'))
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func record(t *testing.T, a *Auditor, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		ev := AuditEvent{Actor: "alice", Action: "update", Resource: "order", Outcome: OutcomeSuccess}
+		if err := a.Record(context.Background(), ev); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key := []byte("secret")
+
+	tests := []struct {
+		name     string
+		key      []byte
+		prevHash string
+		tamper   func(lines []string) []string
+		wantErr  error
+	}{
+		{
+			name:   "intact",
+			key:    key,
+			tamper: func(lines []string) []string { return lines },
+		},
+		{
+			name: "modified record",
+			key:  key,
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"actor":"alice"`, `"actor":"mallory"`, 1)
+				return lines
+			},
+			wantErr: ErrChainBroken,
+		},
+		{
+			name: "removed record",
+			key:  key,
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantErr: ErrChainBroken,
+		},
+		{
+			name: "reordered records",
+			key:  key,
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantErr: ErrChainBroken,
+		},
+		{
+			name:    "wrong key",
+			key:     []byte("other"),
+			tamper:  func(lines []string) []string { return lines },
+			wantErr: ErrChainBroken,
+		},
+		{
+			name:     "unexpected start of chain",
+			key:      key,
+			prevHash: "previous-file",
+			tamper:   func(lines []string) []string { return lines },
+			wantErr:  ErrChainBroken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memorySink{}
+			a, err := New(sink, Options{Key: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			record(t, a, 4)
+
+			lines := tt.tamper(strings.Split(strings.TrimSpace(sink.buf.String()), "\n"))
+			last, err := Verify(strings.NewReader(strings.Join(lines, "\n")), tt.key, tt.prevHash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && last != a.prevHash {
+				t.Errorf("Verify() = %q, want the hash of the last record %q", last, a.prevHash)
+			}
+		})
+	}
+}
+
+func TestVerifyFiles(t *testing.T) {
+	key := []byte("secret")
+
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, backups []string, current string)
+		wantErr error
+	}{
+		{
+			name:   "intact",
+			tamper: func(*testing.T, []string, string) {},
+		},
+		{
+			name: "modified rotated file",
+			tamper: func(t *testing.T, backups []string, _ string) {
+				replaceInFile(t, backups[0], `"outcome":"success"`, `"outcome":"failure"`)
+			},
+			wantErr: ErrChainBroken,
+		},
+		{
+			name: "removed oldest rotated file",
+			tamper: func(t *testing.T, backups []string, _ string) {
+				removeFile(t, backups[0])
+			},
+			wantErr: ErrChainBroken,
+		},
+		{
+			name: "removed middle rotated file",
+			tamper: func(t *testing.T, backups []string, _ string) {
+				removeFile(t, backups[1])
+			},
+			wantErr: ErrChainBroken,
+		},
+		{
+			name: "modified current file",
+			tamper: func(t *testing.T, _ []string, current string) {
+				replaceInFile(t, current, `"action":"update"`, `"action":"delete"`)
+			},
+			wantErr: ErrChainBroken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			sink, err := NewFileSink(logger.FileSinkOptions{Dir: dir, Filename: "audit.log"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := New(sink, Options{Key: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				record(t, a, 2)
+				if err := sink.file.Rotate(); err != nil {
+					t.Fatal(err)
+				}
+				// Backups are named by the millisecond they were rotated at.
+				time.Sleep(2 * time.Millisecond)
+			}
+			record(t, a, 2)
+			if err := a.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			current := filepath.Join(dir, "audit.log")
+			backups, err := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+			if err != nil || len(backups) != 2 {
+				t.Fatalf("rotated files = %v, %v, want 2", backups, err)
+			}
+			sort.Strings(backups)
+			tt.tamper(t, backups, current)
+
+			if err := VerifyFiles(current, key); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyFiles() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSinkResumesChain(t *testing.T) {
+	dir := t.TempDir()
+	opts := logger.FileSinkOptions{Dir: dir, Filename: "audit.log"}
+	key := []byte("secret")
+
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSink(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := New(sink, Options{Key: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		record(t, a, 2)
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := VerifyFiles(filepath.Join(dir, "audit.log"), key); err != nil {
+		t.Errorf("VerifyFiles() error = %v after a restart", err)
+	}
+}
+
+func replaceInFile(t *testing.T, path string, old string, new string) {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte(old)) {
+		t.Fatalf("%s does not contain %s", path, old)
+	}
+	if err := os.WriteFile(path, bytes.Replace(raw, []byte(old), []byte(new), 1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func removeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+}
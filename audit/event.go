@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	// OutcomeDenied is recorded when the caller is not authenticated or not allowed.
+	OutcomeDenied = "denied"
+
+	SourceHttp = "HTTP"
+	SourceGrpc = "GRPC"
+)
+
+// AuditEvent records who did what, to which resource, and with which outcome.
+// PrevHash and Hash chain every record to the previous one; see Verify.
+type AuditEvent struct {
+	ID         string            `json:"id"`
+	Time       time.Time         `json:"time"`
+	Actor      string            `json:"actor"`
+	Action     string            `json:"action"`
+	Resource   string            `json:"resource"`
+	ResourceID string            `json:"resource_id,omitempty"`
+	Outcome    string            `json:"outcome"`
+	Reason     string            `json:"reason,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	TraceID    string            `json:"trace_id,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+}
+
+type actorKey struct{}
+
+// WithActor stores the authenticated actor, e.g. a user or service ID, for the
+// audit middleware. Authentication middleware is expected to call it.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Rule annotates a route or gRPC method whose calls are audited.
+type Rule struct {
+	Action   string
+	Resource string
+	// ResourceParam names the echo path parameter, or the request message field for
+	// gRPC, holding the resource ID.
+	ResourceParam string
+}
+
+// Rules maps "METHOD /route/:param" for echo, or the full gRPC method, to its Rule.
+type Rules map[string]Rule
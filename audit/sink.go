@@ -0,0 +1,203 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"github.com/diki-haryadi/ztools/constant"
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	kafkaProducer "github.com/diki-haryadi/ztools/kafka/producer"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// FileSink appends one JSON record per line to a rotating file kept apart from the
+// application logs.
+type FileSink struct {
+	file *logger.FileSink
+}
+
+func NewFileSink(opts logger.FileSinkOptions) (*FileSink, error) {
+	if opts.Dir == "" {
+		opts.Dir = constant.AuditDir
+	}
+	if opts.Filename == "" {
+		opts.Filename = constant.AuditFile
+	}
+	fs, err := logger.NewFileSink(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{file: fs}, nil
+}
+
+func (s *FileSink) Write(_ context.Context, _ *AuditEvent, record []byte) error {
+	_, err := s.file.Write(append(record, '\n'))
+	return err
+}
+
+// LastHash returns the hash of the last record of the current file, if any.
+func (s *FileSink) LastHash() (string, error) {
+	f, err := os.Open(s.file.Filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if last == nil {
+		return "", nil
+	}
+
+	var ev AuditEvent
+	if err := json.Unmarshal(last, &ev); err != nil {
+		return "", errors.Wrapf(err, "audit: last record of %s", s.file.Filename)
+	}
+
+	return ev.Hash, nil
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// KafkaSink publishes records to the topic of its writer. Every record uses the same
+// key, so with a kafka.Hash balancer they land on one partition and keep the order
+// of the chain. The chain is not resumed across restarts: each run starts one with
+// an empty PrevHash.
+//
+// Write only queues the record, so Auditor.Record never waits for the brokers; a
+// single goroutine publishes the queue in batches, retries a failing batch and logs
+// the batches it still could not write, which then show up as a broken chain.
+type KafkaSink struct {
+	writer *kafkaProducer.Writer
+	key    []byte
+	queue  chan kafka.Message
+	done   chan struct{}
+
+	// mu keeps Close from closing the queue while Write sends on it.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewKafkaSink(writer *kafkaProducer.Writer, key string) *KafkaSink {
+	s := &KafkaSink{
+		writer: writer,
+		key:    []byte(key),
+		queue:  make(chan kafka.Message, constant.AuditKafkaQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.publish()
+
+	return s
+}
+
+func (s *KafkaSink) Write(ctx context.Context, ev *AuditEvent, record []byte) error {
+	msg := kafka.Message{
+		Key:   s.key,
+		Value: record,
+		Headers: []kafka.Header{
+			{Key: "audit-id", Value: []byte(ev.ID)},
+			{Key: "audit-action", Value: []byte(ev.Action)},
+		},
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+	select {
+	case s.queue <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *KafkaSink) publish() {
+	defer close(s.done)
+
+	batch := make([]kafka.Message, 0, constant.AuditKafkaBatchSize)
+	for msg := range s.queue {
+		batch = append(batch[:0], msg)
+	drain:
+		for len(batch) < cap(batch) {
+			select {
+			case next, ok := <-s.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+
+		s.write(batch)
+	}
+}
+
+// write publishes batch, retrying with a doubling backoff, and logs it when it is
+// dropped.
+func (s *KafkaSink) write(batch []kafka.Message) {
+	l := logger.FromContext(context.Background()).Named(loggerName)
+	backoff := constant.AuditKafkaRetryBackoff
+
+	var err error
+	for attempt := 1; attempt <= constant.AuditKafkaAttempts; attempt++ {
+		if err = s.writer.Client.WriteMessages(context.Background(), batch...); err == nil {
+			return
+		}
+		if attempt < constant.AuditKafkaAttempts {
+			l.Warn("could not publish audit records, retrying",
+				zap.String(loggerConstant.TOPIC, s.writer.Client.Topic),
+				zap.String(loggerConstant.ERR, err.Error()),
+			)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	l.Error("could not publish audit records, dropping them",
+		zap.String(loggerConstant.TOPIC, s.writer.Client.Topic),
+		zap.Int(loggerConstant.COUNT, len(batch)),
+		zap.String(loggerConstant.ERR, err.Error()),
+	)
+}
+
+// Close publishes the queued records, then closes the writer. Write fails with
+// ErrSinkClosed afterwards.
+func (s *KafkaSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+
+	return s.writer.Client.Close()
+}
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Backend stores encoded values by key.
+type Backend interface {
+	// Get reports false when key is not stored or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for ttl; zero keeps it until evicted or deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// RedisBackend stores values in Redis, e.g. the client of redis.NewUniversalRedisClient.
+type RedisBackend struct {
+	client redis.UniversalClient
+}
+
+func NewRedisBackend(client redis.UniversalClient) *RedisBackend {
+	return &RedisBackend{client: client}
+}
+
+func (b *RedisBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := b.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+// getWithTTL also returns the remaining TTL of key, zero when it does not expire.
+func (b *RedisBackend) getWithTTL(ctx context.Context, key string) ([]byte, time.Duration, bool, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, 0, false, err
+	}
+	value, err := get.Bytes()
+	if err == redis.Nil {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	// PTTL is negative for keys without expiry.
+	return value, max(pttl.Val(), 0), true, nil
+}
+
+func (b *RedisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (b *RedisBackend) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return b.client.Del(ctx, keys...).Err()
+}
+
+// LRUBackend is a bounded in-process backend, for tests and single-replica services.
+type LRUBackend struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUBackend keeps up to size entries, evicting the least recently used first.
+func NewLRUBackend(size int) *LRUBackend {
+	if size <= 0 {
+		size = 1024
+	}
+
+	return &LRUBackend{size: size, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+func (b *LRUBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	el, ok := b.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := el.Value.(*lruItem)
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		b.remove(el)
+		return nil, false, nil
+	}
+	b.ll.MoveToFront(el)
+
+	return item.value, true, nil
+}
+
+func (b *LRUBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if el, ok := b.items[key]; ok {
+		item := el.Value.(*lruItem)
+		item.value, item.expiresAt = value, expiresAt
+		b.ll.MoveToFront(el)
+		return nil
+	}
+
+	b.items[key] = b.ll.PushFront(&lruItem{key: key, value: value, expiresAt: expiresAt})
+	for b.ll.Len() > b.size {
+		b.remove(b.ll.Back())
+	}
+
+	return nil
+}
+
+func (b *LRUBackend) Delete(_ context.Context, keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := b.items[key]; ok {
+			b.remove(el)
+		}
+	}
+
+	return nil
+}
+
+func (b *LRUBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.ll.Len()
+}
+
+func (b *LRUBackend) remove(el *list.Element) {
+	b.ll.Remove(el)
+	delete(b.items, el.Value.(*lruItem).key)
+}
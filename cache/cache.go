@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// loggerName lets LOG_LEVELS=cache=debug change the level of the cache logs only.
+const loggerName = "cache"
+
+const defaultLoadTimeout = 30 * time.Second
+
+var (
+	// ErrMiss is returned by Get when the key is not cached.
+	ErrMiss = errors.New("cache: miss")
+	// ErrNotFound is returned by a loader when the value does not exist; GetOrLoad then
+	// caches the absence for Options.NegativeTTL and returns it to every caller.
+	ErrNotFound = errors.New("cache: not found")
+)
+
+// Every stored value starts with a marker byte telling values from cached absences.
+const (
+	markerValue    byte = 0
+	markerNotFound byte = 1
+)
+
+type Options struct {
+	// Namespace prefixes every key, usually with the App.AppName of the configuration,
+	// so services sharing a Redis do not collide.
+	Namespace string
+	// NegativeTTL caches ErrNotFound from loaders; zero disables negative caching.
+	NegativeTTL time.Duration
+	// LoadTimeout bounds a loader of GetOrLoad, 30s when zero. A loader also stops at
+	// the deadline of the caller that started it, when that comes first.
+	LoadTimeout time.Duration
+}
+
+// Cache is a typed cache-aside layer over a Backend.
+type Cache[T any] struct {
+	backend     Backend
+	codec       Codec[T]
+	namespace   string
+	negativeTTL time.Duration
+	loadTimeout time.Duration
+	group       singleflight.Group
+}
+
+// New builds a cache of T, e.g.
+//
+//	cache.New[Article](cache.NewRedisBackend(ic.Redis), cache.JSONCodec[Article]{},
+//		cache.Options{Namespace: config.BaseConfig.App.AppName, NegativeTTL: time.Minute})
+func New[T any](backend Backend, codec Codec[T], opts Options) *Cache[T] {
+	loadTimeout := opts.LoadTimeout
+	if loadTimeout <= 0 {
+		loadTimeout = defaultLoadTimeout
+	}
+
+	return &Cache[T]{
+		backend:     backend,
+		codec:       codec,
+		namespace:   opts.Namespace,
+		negativeTTL: opts.NegativeTTL,
+		loadTimeout: loadTimeout,
+	}
+}
+
+// Key returns the backend key of key, with the namespace.
+func (c *Cache[T]) Key(key string) string {
+	if c.namespace == "" {
+		return key
+	}
+
+	return c.namespace + ":" + key
+}
+
+// Get returns ErrMiss when key is not cached and ErrNotFound when its absence is.
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	var zero T
+	b, ok, err := c.backend.Get(ctx, c.Key(key))
+	if err != nil {
+		return zero, err
+	}
+	if !ok || len(b) == 0 {
+		return zero, ErrMiss
+	}
+
+	switch b[0] {
+	case markerNotFound:
+		return zero, ErrNotFound
+	case markerValue:
+		return c.codec.Unmarshal(b[1:])
+	default:
+		return zero, errors.Errorf("cache: unknown marker %d for %s", b[0], key)
+	}
+}
+
+func (c *Cache[T]) Set(ctx context.Context, key string, v T, ttl time.Duration) error {
+	b, err := c.codec.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "cache: marshal")
+	}
+
+	return c.backend.Set(ctx, c.Key(key), append([]byte{markerValue}, b...), ttl)
+}
+
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	full := make([]string, len(keys))
+	for i, key := range keys {
+		full[i] = c.Key(key)
+	}
+
+	return c.backend.Delete(ctx, full...)
+}
+
+// GetOrLoad returns the cached value of key or loads, caches and returns it. Concurrent
+// callers of the same key share one loader call. Backend failures are logged and
+// fall back to the loader, so the cache never fails a request on its own.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error), ttl time.Duration) (T, error) {
+	v, err := c.Get(ctx, key)
+	switch {
+	case err == nil, errors.Is(err, ErrNotFound):
+		return v, err
+	case !errors.Is(err, ErrMiss):
+		c.warn(ctx, "cache get failed", key, err)
+	}
+
+	ch := c.group.DoChan(c.Key(key), func() (res interface{}, err error) {
+		// The loader must not fail the callers sharing it when the first one goes
+		// away, but it keeps its deadline.
+		loadCtx, cancel := c.loadContext(ctx)
+		defer cancel()
+		// singleflight re-panics in a new goroutine, which no caller could recover.
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.Errorf("cache: loader of %s panicked: %v", c.Key(key), r)
+			}
+		}()
+
+		v, err := loader(loadCtx)
+		switch {
+		case err == nil:
+			if serr := c.Set(loadCtx, key, v, ttl); serr != nil {
+				c.warn(loadCtx, "cache set failed", key, serr)
+			}
+		case errors.Is(err, ErrNotFound) && c.negativeTTL > 0:
+			if serr := c.backend.Set(loadCtx, c.Key(key), []byte{markerNotFound}, c.negativeTTL); serr != nil {
+				c.warn(loadCtx, "cache set failed", key, serr)
+			}
+		}
+		return v, err
+	})
+
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case res := <-ch:
+		v, _ := res.Val.(T)
+		return v, res.Err
+	}
+}
+
+func (c *Cache[T]) loadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(c.loadTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	return context.WithDeadline(context.WithoutCancel(ctx), deadline)
+}
+
+func (c *Cache[T]) warn(ctx context.Context, msg string, key string, err error) {
+	logger.FromContext(ctx).Named(loggerName).Warn(msg,
+		zap.String(loggerConstant.NAME, c.Key(key)),
+		zap.String(loggerConstant.ERR, err.Error()),
+	)
+}
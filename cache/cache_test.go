@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errBackendDown = errors.New("backend down")
+
+func TestGetOrLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		negativeTTL time.Duration
+		loadVal     string
+		loadErr     error
+		wantVal     string
+		wantErr     error
+		wantCalls   int32
+		wantGetErr  error
+	}{
+		{
+			name:      "value is cached",
+			loadVal:   "article",
+			wantVal:   "article",
+			wantCalls: 1,
+		},
+		{
+			name:        "absence is cached",
+			negativeTTL: time.Minute,
+			loadErr:     ErrNotFound,
+			wantErr:     ErrNotFound,
+			wantCalls:   1,
+			wantGetErr:  ErrNotFound,
+		},
+		{
+			name:       "absence is not cached without a negative ttl",
+			loadErr:    ErrNotFound,
+			wantErr:    ErrNotFound,
+			wantCalls:  2,
+			wantGetErr: ErrMiss,
+		},
+		{
+			name:        "failure is not cached",
+			negativeTTL: time.Minute,
+			loadErr:     errBackendDown,
+			wantErr:     errBackendDown,
+			wantCalls:   2,
+			wantGetErr:  ErrMiss,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[string](NewLRUBackend(0), JSONCodec[string]{}, Options{Namespace: "test", NegativeTTL: tt.negativeTTL})
+			var calls atomic.Int32
+			loader := func(context.Context) (string, error) {
+				calls.Add(1)
+				return tt.loadVal, tt.loadErr
+			}
+
+			for i := 0; i < 2; i++ {
+				v, err := c.GetOrLoad(context.Background(), "1", loader, time.Minute)
+				if !errors.Is(err, tt.wantErr) || v != tt.wantVal {
+					t.Fatalf("call %d: GetOrLoad() = %q, %v, want %q, %v", i, v, err, tt.wantVal, tt.wantErr)
+				}
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("loader called %d times, want %d", got, tt.wantCalls)
+			}
+			if _, err := c.Get(context.Background(), "1"); !errors.Is(err, tt.wantGetErr) {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantGetErr)
+			}
+		})
+	}
+}
+
+func TestGetOrLoadNegativeTTLExpires(t *testing.T) {
+	c := New[string](NewLRUBackend(0), JSONCodec[string]{}, Options{NegativeTTL: 20 * time.Millisecond})
+	var calls atomic.Int32
+	loader := func(context.Context) (string, error) {
+		calls.Add(1)
+		return "", ErrNotFound
+	}
+
+	for _, wait := range []time.Duration{0, 0, 30 * time.Millisecond} {
+		time.Sleep(wait)
+		if _, err := c.GetOrLoad(context.Background(), "1", loader, time.Minute); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("GetOrLoad() error = %v, want ErrNotFound", err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("loader called %d times, want 2", got)
+	}
+}
+
+func TestGetOrLoadSharesLoader(t *testing.T) {
+	tests := []struct {
+		name    string
+		loadErr error
+	}{
+		{name: "value"},
+		{name: "not found", loadErr: ErrNotFound},
+		{name: "failure", loadErr: errBackendDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[string](NewLRUBackend(0), JSONCodec[string]{}, Options{NegativeTTL: time.Minute})
+			var calls atomic.Int32
+			started := make(chan struct{})
+			release := make(chan struct{})
+			loader := func(context.Context) (string, error) {
+				if calls.Add(1) == 1 {
+					close(started)
+				}
+				<-release
+				return "article", tt.loadErr
+			}
+
+			const callers = 10
+			var wg sync.WaitGroup
+			errs := make(chan error, callers)
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := c.GetOrLoad(context.Background(), "1", loader, time.Minute)
+					errs <- err
+				}()
+			}
+			<-started
+			// Let every caller join the load in flight before it returns.
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+			close(errs)
+
+			if got := calls.Load(); got != 1 {
+				t.Errorf("loader called %d times, want 1", got)
+			}
+			for err := range errs {
+				if !errors.Is(err, tt.loadErr) {
+					t.Errorf("GetOrLoad() error = %v, want %v", err, tt.loadErr)
+				}
+			}
+		})
+	}
+}
+
+func TestGetOrLoadCallerGoesAway(t *testing.T) {
+	c := New[string](NewLRUBackend(0), JSONCodec[string]{}, Options{})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "article", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "1", loader, time.Minute)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), "1", loader, time.Minute)
+		second <- v
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled GetOrLoad() error = %v, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-second; v != "article" {
+		t.Errorf("shared GetOrLoad() = %q, want the value of the loader", v)
+	}
+}
+
+func TestGetOrLoadRecoversLoaderPanic(t *testing.T) {
+	c := New[string](NewLRUBackend(0), JSONCodec[string]{}, Options{})
+	_, err := c.GetOrLoad(context.Background(), "1", func(context.Context) (string, error) {
+		panic("boom")
+	}, time.Minute)
+	if err == nil {
+		t.Fatal("GetOrLoad() error = nil after a loader panic")
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes the values of a Cache[T].
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(b []byte) (T, error)
+}
+
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[T]) Unmarshal(b []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+type MsgpackCodec[T any] struct{}
+
+func (MsgpackCodec[T]) Marshal(v T) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgpackCodec[T]) Unmarshal(b []byte) (T, error) {
+	var v T
+	err := msgpack.Unmarshal(b, &v)
+	return v, err
+}
+
+// ProtoCodec encodes generated messages in the protobuf wire format, e.g.
+// ProtoCodec[*articleV1.Article]{}.
+type ProtoCodec[T proto.Message] struct{}
+
+func (ProtoCodec[T]) Marshal(v T) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+func (ProtoCodec[T]) Unmarshal(b []byte) (T, error) {
+	var zero T
+	// Generated messages describe their type even through a nil pointer.
+	v := zero.ProtoReflect().Type().New().Interface().(T)
+	err := proto.Unmarshal(b, v)
+	return v, err
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+const (
+	nearSize     = 1024
+	nearLocalTTL = 30 * time.Second
+	nearChannel  = "cache:invalidate"
+)
+
+type NearOptions struct {
+	// Size bounds the in-process tier, 1024 entries by default.
+	Size int
+	// LocalTTL caps how long a replica serves its copy, 30s by default. It also bounds
+	// the staleness after an invalidation is lost, e.g. while reconnecting.
+	LocalTTL time.Duration
+	// Channel carries the invalidations, usually prefixed with App.AppName so that only
+	// the replicas of the same service listen to each other.
+	Channel string
+}
+
+// NearBackend holds values in a bounded in-process LRU in front of Redis. Writes and
+// deletes go to Redis and publish the keys on a pub/sub channel so the other replicas
+// evict their copies.
+type NearBackend struct {
+	local    *LRUBackend
+	remote   *RedisBackend
+	client   redis.UniversalClient
+	channel  string
+	localTTL time.Duration
+	origin   string
+
+	// epoch changes on every write and invalidation, so Get can tell that a value it
+	// read from Redis may have been replaced meanwhile.
+	epoch atomic.Uint64
+
+	pubsub *redis.PubSub
+	done   chan struct{}
+	once   sync.Once
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// NewNearBackend subscribes to the invalidation channel; Close unsubscribes.
+func NewNearBackend(ctx context.Context, client redis.UniversalClient, opts NearOptions) (*NearBackend, error) {
+	b := &NearBackend{
+		local:    NewLRUBackend(orDefault(opts.Size, nearSize)),
+		remote:   NewRedisBackend(client),
+		client:   client,
+		channel:  orDefault(opts.Channel, nearChannel),
+		localTTL: orDefault(opts.LocalTTL, nearLocalTTL),
+		origin:   newOrigin(),
+		done:     make(chan struct{}),
+	}
+
+	b.pubsub = client.Subscribe(ctx, b.channel)
+	// Wait for the subscription, otherwise the first invalidations could be missed.
+	if _, err := b.pubsub.Receive(ctx); err != nil {
+		_ = b.pubsub.Close()
+		return nil, err
+	}
+	go b.listen(ctx)
+
+	return b, nil
+}
+
+func (b *NearBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok, _ := b.local.Get(ctx, key); ok {
+		return value, true, nil
+	}
+
+	epoch := b.epoch.Load()
+	value, ttl, ok, err := b.remote.getWithTTL(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	localTTL := b.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	_ = b.local.Set(ctx, key, value, localTTL)
+	// Writers bump the epoch before touching the local tier, so either they replace
+	// this copy or the change shows here.
+	if b.epoch.Load() != epoch {
+		_ = b.local.Delete(ctx, key)
+	}
+
+	return value, true, nil
+}
+
+func (b *NearBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := b.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	b.epoch.Add(1)
+	localTTL := b.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	_ = b.local.Set(ctx, key, value, localTTL)
+
+	return b.publish(ctx, key)
+}
+
+func (b *NearBackend) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := b.remote.Delete(ctx, keys...)
+	b.epoch.Add(1)
+	_ = b.local.Delete(ctx, keys...)
+	if err != nil {
+		return err
+	}
+
+	return b.publish(ctx, keys...)
+}
+
+// Close stops listening for invalidations; the client stays open.
+func (b *NearBackend) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.done)
+		err = b.pubsub.Close()
+	})
+
+	return err
+}
+
+func (b *NearBackend) publish(ctx context.Context, keys ...string) error {
+	msg, err := json.Marshal(invalidation{Origin: b.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(ctx, b.channel, msg).Err()
+}
+
+func (b *NearBackend) listen(ctx context.Context) {
+	ch := b.pubsub.Channel()
+	for {
+		select {
+		case <-b.done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			b.evict(ctx, msg.Payload)
+		}
+	}
+}
+
+func (b *NearBackend) evict(ctx context.Context, payload string) {
+	var inv invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		logger.FromContext(ctx).Named(loggerName).Warn("invalid cache invalidation",
+			zap.String(loggerConstant.NAME, b.channel),
+			zap.String(loggerConstant.ERR, err.Error()),
+		)
+		return
+	}
+	// The local tier of the publisher already holds the new value.
+	if inv.Origin == b.origin {
+		return
+	}
+
+	b.epoch.Add(1)
+	_ = b.local.Delete(ctx, inv.Keys...)
+}
+
+func newOrigin() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func orDefault[T int | time.Duration | string](v T, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+
+	return v
+}
@@ -1,118 +1,251 @@
 package config
 
 import (
+	"log"
+	"time"
+
 	"github.com/diki-haryadi/ztools/constant"
 	"github.com/diki-haryadi/ztools/env"
 )
 
 type Config struct {
-	App              AppConfig
-	Grpc             GrpcConfig
-	Http             HttpConfig
-	Postgres         PostgresConfig
-	SampleExtService GrpcConfig
+	App      AppConfig
+	Grpc     GrpcConfig
+	Http     HttpConfig
+	Postgres PostgresConfig
+	Redis    RedisConfig
+	// ExternalServices holds every EXT_<NAME>_(GRPC|HTTP)_* service keyed by
+	// ExternalServiceKey, e.g. "payment/grpc".
+	ExternalServices map[string]ExternalServiceConfig `env:"-"`
 	Kafka            KafkaConfig
 	Sentry           SentryConfig
+	Log              LogConfig
+	Audit            AuditConfig
+	Cron             CronConfig
+	RateLimit        RateLimitConfig
 }
 
+// BaseConfig is the configuration loaded at startup by LoadConfig. It is never
+// replaced afterwards; read Current to see reloads of a Watcher.
 var BaseConfig *Config
 
 type AppConfig struct {
-	AppEnv  string
-	AppName string
+	AppEnv  string `env:"APP_ENV" default:"dev"`
+	AppName string `env:"APP_NAME" default:"Go-Microservice-Template"`
 }
 
 type PostgresConfig struct {
-	Host            string
-	Port            string
-	User            string
-	Pass            string
-	DBName          string
-	MaxConn         int
-	MaxIdleConn     int
-	MaxLifeTimeConn int
-	SslMode         string
+	Host            string        `env:"PG_HOST" required:"true"`
+	Port            string        `env:"PG_PORT" required:"true"`
+	User            string        `env:"PG_USER" required:"true"`
+	Pass            string        `env:"PG_PASS" required:"true" secret:"true"`
+	DBName          string        `env:"PG_DB" required:"true"`
+	MaxConn         int           `env:"PG_MAX_CONNECTIONS" default:"1"`
+	MaxIdleConn     int           `env:"PG_MAX_IDLE_CONNECTIONS" default:"1"`
+	MaxLifeTimeConn time.Duration `env:"PG_MAX_LIFETIME_CONNECTIONS" default:"5m"`
+	SslMode         string        `env:"PG_SSL_MODE" default:"disable"`
+}
+
+// RedisConfig selects a standalone server, a Sentinel failover group by MasterName or
+// a Cluster, all through redis.NewUniversalRedisClient. MaxRetries 0 disables the
+// retries of a command.
+type RedisConfig struct {
+	Mode             string        `env:"REDIS_MODE" default:"standalone"`
+	Addrs            []string      `env:"REDIS_ADDRS" sep:"," default:"localhost:6379"`
+	MasterName       string        `env:"REDIS_MASTER_NAME"`
+	Username         string        `env:"REDIS_USERNAME"`
+	Password         string        `env:"REDIS_PASSWORD" secret:"true"`
+	SentinelPassword string        `env:"REDIS_SENTINEL_PASSWORD" secret:"true"`
+	DB               int           `env:"REDIS_DB" default:"0"`
+	TLS              bool          `env:"REDIS_TLS" default:"false"`
+	PoolSize         int           `env:"REDIS_POOL_SIZE" default:"10"`
+	MinIdleConns     int           `env:"REDIS_MIN_IDLE_CONNS" default:"5"`
+	MaxRetries       int           `env:"REDIS_MAX_RETRIES" default:"5"`
+	MinRetryBackoff  time.Duration `env:"REDIS_MIN_RETRY_BACKOFF" default:"300ms"`
+	MaxRetryBackoff  time.Duration `env:"REDIS_MAX_RETRY_BACKOFF" default:"500ms"`
+	DialTimeout      time.Duration `env:"REDIS_DIAL_TIMEOUT" default:"5s"`
+	ReadTimeout      time.Duration `env:"REDIS_READ_TIMEOUT" default:"5s"`
+	WriteTimeout     time.Duration `env:"REDIS_WRITE_TIMEOUT" default:"3s"`
+	PoolTimeout      time.Duration `env:"REDIS_POOL_TIMEOUT" default:"6s"`
+	IdleTimeout      time.Duration `env:"REDIS_IDLE_TIMEOUT" default:"12s"`
 }
+
 type GrpcConfig struct {
-	Port int
-	Host string
+	Port int    `env:"GRPC_PORT" default:"3000"`
+	Host string `env:"GRPC_HOST" default:"localhost"`
 }
 
 type HttpConfig struct {
-	Port int
-	Host string
+	Port int    `env:"HTTP_PORT" default:"4000"`
+	Host string `env:"HTTP_HOST" default:"localhost"`
+	// LogBodies logs redacted request and response bodies at debug level.
+	LogBodies bool `env:"HTTP_LOG_BODIES" default:"false"`
+	// ReadTimeout and WriteTimeout bound the body read and the response of every
+	// request and follow config reloads; zero disables them.
+	ReadTimeout  time.Duration `env:"HTTP_READ_TIMEOUT" default:"0s"`
+	WriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"0s"`
+	// IdleTimeout closes idle keep-alive connections; it is only read on startup.
+	IdleTimeout time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"0s"`
 }
 
 type KafkaConfig struct {
-	Enabled       bool
-	LogEvents     bool
-	ClientId      string
-	ClientGroupId string
-	ClientBrokers []string
-	Topic         string
+	Enabled       bool     `env:"KAFKA_ENABLED" required:"true"`
+	LogEvents     bool     `env:"KAFKA_LOG_EVENTS" required:"true"`
+	ClientId      string   `env:"KAFKA_CLIENT_ID" required:"true"`
+	ClientGroupId string   `env:"KAFKA_CLIENT_GROUP_ID" required:"true"`
+	ClientBrokers []string `env:"KAFKA_CLIENT_BROKERS" sep:"," required:"true"`
+	Topic         string   `env:"KAFKA_TOPIC" required:"true"`
+}
+
+type LogConfig struct {
+	Level string `env:"LOG_LEVEL" default:"debug"`
+	// Levels overrides Level per named logger, e.g. LOG_LEVELS=kafka=debug,grpc=warn.
+	Levels map[string]string `env:"LOG_LEVELS"`
+	// AdminPath registers the log level endpoint on the echo server; empty disables it.
+	AdminPath string `env:"LOG_ADMIN_PATH"`
+	// Sampling keeps the first N entries with the same message per SampleTick, then every
+	// Mth, written "N:M"; "off" disables it. Errors are never sampled.
+	Sampling   string        `env:"LOG_SAMPLING" default:"off"`
+	SampleTick time.Duration `env:"LOG_SAMPLE_TICK" default:"1s"`
+	// SampleRoutes overrides Sampling per echo route or gRPC method, e.g.
+	// LOG_SAMPLE_ROUTES=/api/v1/health=1:0,/grpc.health.v1.Health/Check=off.
+	SampleRoutes map[string]string `env:"LOG_SAMPLE_ROUTES"`
+	// Schema names the log keys: bracketed, ecs, otel or gcp.
+	Schema string `env:"LOG_SCHEMA" default:"bracketed"`
+	// GCPProject is the project of the trace ids written by the gcp schema.
+	GCPProject string `env:"LOG_GCP_PROJECT"`
+	// RedactKeys extends the field names that are always masked in logs and Sentry events.
+	RedactKeys []string `env:"LOG_REDACT_KEYS" sep:","`
+	// AdminGrpc registers the log level admin service on the gRPC server.
+	AdminGrpc bool `env:"LOG_ADMIN_GRPC" default:"false"`
+	// Sinks combines console, stdout, stderr and file. When empty the profile decides:
+	// console with ConsoleLogs, file otherwise.
+	Sinks []string `env:"LOG_SINKS" sep:","`
+	// Dir and File default to constant.LogDir and constant.LogFile.
+	Dir        string        `env:"LOG_DIR"`
+	File       string        `env:"LOG_FILE"`
+	MaxSize    env.ByteSize  `env:"LOG_MAX_SIZE" default:"100MB"`
+	MaxBackups int           `env:"LOG_MAX_BACKUPS" default:"7"`
+	MaxAge     time.Duration `env:"LOG_MAX_AGE" default:"168h"`
+	Compress   bool          `env:"LOG_COMPRESS" default:"true"`
+	// RotateInterval rotates the file on a fixed schedule on top of MaxSize; zero disables it.
+	RotateInterval time.Duration `env:"LOG_ROTATE_INTERVAL" default:"0s"`
+}
+
+type CronConfig struct {
+	// AdminPath serves the cron admin routes on the echo server; empty disables them.
+	AdminPath string `env:"CRON_ADMIN_PATH"`
+	// History stores run records in memory or in the Postgres HistoryTable.
+	History      string `env:"CRON_HISTORY" default:"memory"`
+	HistorySize  int    `env:"CRON_HISTORY_SIZE" default:"100"`
+	HistoryTable string `env:"CRON_HISTORY_TABLE" default:"cron_runs"`
+	// Pauses keeps the paused jobs in memory, for the replica that is called only, or
+	// in Redis or the Postgres PauseTable, for every replica.
+	Pauses     string `env:"CRON_PAUSES" default:"memory"`
+	PauseTable string `env:"CRON_PAUSE_TABLE" default:"cron_pauses"`
+}
+
+// RateLimitConfig limits every HTTP request and gRPC call per client IP and per valid
+// API key, in Redis when it is connected and in memory otherwise.
+type RateLimitConfig struct {
+	Enabled bool `env:"RATE_LIMIT_ENABLED" default:"false"`
+	// Algorithm is sliding_window or token_bucket.
+	Algorithm string        `env:"RATE_LIMIT_ALGORITHM" default:"sliding_window"`
+	Rate      int           `env:"RATE_LIMIT_RATE" default:"100"`
+	Period    time.Duration `env:"RATE_LIMIT_PERIOD" default:"1m"`
+	// Burst is the capacity of the token bucket, Rate when zero.
+	Burst int `env:"RATE_LIMIT_BURST" default:"0"`
+	// APIKeyHeader names the header, or gRPC metadata, carrying the API key. The keys
+	// are only limited once IContainer.IRateLimitKeyValidator accepts them.
+	APIKeyHeader string `env:"RATE_LIMIT_API_KEY_HEADER"`
+	// TrustedProxies are the CIDRs or IPs of the proxies whose X-Forwarded-For sets the
+	// client IP; empty limits the peer address.
+	TrustedProxies []string `env:"RATE_LIMIT_TRUSTED_PROXIES" sep:","`
+	// RedisTimeout bounds every Redis call, and RedisCooldown is how long the limiter
+	// stays in memory after one failed.
+	RedisTimeout  time.Duration `env:"RATE_LIMIT_REDIS_TIMEOUT" default:"100ms"`
+	RedisCooldown time.Duration `env:"RATE_LIMIT_REDIS_COOLDOWN" default:"5s"`
+}
+
+// AuditConfig selects where audit records are written, apart from the application logs.
+type AuditConfig struct {
+	Enabled bool   `env:"AUDIT_ENABLED" default:"false"`
+	Sink    string `env:"AUDIT_SINK" default:"file"`
+	// Dir and File default to constant.AuditDir and constant.AuditFile.
+	Dir  string `env:"AUDIT_DIR"`
+	File string `env:"AUDIT_FILE"`
+	// MaxSize rotates the file; rotated files are kept, audit records are never deleted.
+	MaxSize env.ByteSize `env:"AUDIT_MAX_SIZE" default:"100MB"`
+	// Topic is the Kafka topic of the kafka sink, written with the KAFKA_CLIENT_BROKERS.
+	Topic string `env:"AUDIT_TOPIC"`
+	// HashKey signs the hash chain with HMAC-SHA256 when set.
+	HashKey string `env:"AUDIT_HASH_KEY" secret:"true"`
 }
 
 type SentryConfig struct {
-	Dsn string
+	Dsn string `env:"SENTRY_DSN" required:"true" secret:"true"`
 }
 
 func init() {
 	//BaseConfig = newConfig()
 }
 
-func NewConfig() *Config {
-	config := &Config{
-		App: AppConfig{
-			AppEnv:  env.New("APP_ENV", constant.AppEnvDev).AsString(),
-			AppName: env.New("APP_NAME", constant.AppName).AsString(),
-		},
-		Grpc: GrpcConfig{
-			Port: env.New("GRPC_PORT", constant.GrpcPort).AsInt(),
-			Host: env.New("GRPC_HOST", constant.GrpcHost).AsString(),
-		},
-		Http: HttpConfig{
-			Port: env.New("HTTP_PORT", constant.HttpPort).AsInt(),
-			Host: env.New("HTTP_HOST", constant.HttpHost).AsString(),
-		},
-		Postgres: PostgresConfig{
-			Host:            env.New("PG_HOST", nil).AsString(),
-			Port:            env.New("PG_PORT", nil).AsString(),
-			User:            env.New("PG_USER", nil).AsString(),
-			Pass:            env.New("PG_PASS", nil).AsString(),
-			DBName:          env.New("PG_DB", nil).AsString(),
-			MaxConn:         env.New("PG_MAX_CONNECTIONS", constant.PgMaxConn).AsInt(),
-			MaxIdleConn:     env.New("PG_MAX_IDLE_CONNECTIONS", constant.PgMaxIdleConn).AsInt(),
-			MaxLifeTimeConn: env.New("PG_MAX_LIFETIME_CONNECTIONS", constant.PgMaxLifeTimeConn).AsInt(),
-			SslMode:         env.New("PG_SSL_MODE", constant.PgSslMode).AsString(),
-		},
-		SampleExtService: GrpcConfig{
-			Port: env.New("SAMPLE_EXT_SERVICE_GRPC_PORT", constant.GrpcPort).AsInt(),
-			Host: env.New("SAMPLE_EXT_SERVICE_GRPC_HOST", constant.GrpcHost).AsString(),
-		},
-		Kafka: KafkaConfig{
-			Enabled:       env.New("KAFKA_ENABLED", nil).AsBool(),
-			LogEvents:     env.New("KAFKA_LOG_EVENTS", nil).AsBool(),
-			ClientId:      env.New("KAFKA_CLIENT_ID", nil).AsString(),
-			ClientGroupId: env.New("KAFKA_CLIENT_GROUP_ID", nil).AsString(),
-			ClientBrokers: env.New("KAFKA_CLIENT_BROKERS", nil).AsStringSlice(","),
-			Topic:         env.New("KAFKA_TOPIC", nil).AsString(),
-		},
-		Sentry: SentryConfig{
-			Dsn: env.New("SENTRY_DSN", nil).AsString(),
-		},
+// LoadConfig reads the whole configuration from env using the struct tags above and
+// reports every missing or invalid variable in a single env.Errors instead of exiting
+// on the first one. A configuration that loads but fails Validate is rejected too.
+// Values are resolved through env.CurrentLayers, so call
+// env.SetLayers(env.NewStandardLayers(...)) first to add config files, .env files
+// and flags; env.Origins then tells which source each value came from.
+func LoadConfig() (*Config, error) {
+	config, err := load(env.CurrentLayers())
+	if err != nil {
+		return nil, err
 	}
+
 	BaseConfig = config
+	current.Store(config)
+	return config, nil
+}
+
+// load reads a snapshot from layers, which need not be the published ones.
+func load(layers *env.Layers) (*Config, error) {
+	applyProfileDefaults(layers)
+
+	config := &Config{}
+	if err := layers.Load(config); err != nil {
+		return nil, err
+	}
+
+	services, err := loadExternalServices(layers)
+	if err != nil {
+		return nil, err
+	}
+	config.ExternalServices = services
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func NewConfig() *Config {
+	config, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
 	return config
 }
 
 func IsDevEnv() bool {
-	return BaseConfig.App.AppEnv == constant.AppEnvDev
+	return Current().App.AppEnv == constant.AppEnvDev
 }
 
 func IsProdEnv() bool {
-	return BaseConfig.App.AppEnv == constant.AppEnvProd
+	return Current().App.AppEnv == constant.AppEnvProd
 }
 
 func IsTestEnv() bool {
-	return BaseConfig.App.AppEnv == constant.AppEnvTest
+	return Current().App.AppEnv == constant.AppEnvTest
 }
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/diki-haryadi/ztools/env"
+)
+
+type DumpFormat string
+
+const (
+	DumpJSON  DumpFormat = "json"
+	DumpTable DumpFormat = "table"
+)
+
+// Dump renders the effective configuration for startup diagnostics: every env key
+// with its value, secrets redacted, and the source it was loaded from.
+func (c *Config) Dump(format DumpFormat) (string, error) {
+	fields, err := env.Describe(c)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(c.ExternalServices))
+	for name := range c.ExternalServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		svc := c.ExternalServices[name]
+		svcFields, err := env.DescribeWithPrefix(svc.Prefix, &svc)
+		if err != nil {
+			return "", err
+		}
+		for i := range svcFields {
+			svcFields[i].Path = "ExternalServices." + name + "." + svcFields[i].Path
+		}
+		fields = append(fields, svcFields...)
+	}
+
+	switch format {
+	case DumpJSON:
+		b, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+
+	case DumpTable:
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "KEY\tFIELD\tVALUE\tSOURCE")
+		for _, f := range fields {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Key, f.Path, f.Value, f.Source)
+		}
+		if err := w.Flush(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+
+	default:
+		return "", errors.Errorf("config: unsupported dump format %q", format)
+	}
+}
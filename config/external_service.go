@@ -0,0 +1,118 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	validator "github.com/go-ozzo/ozzo-validation"
+
+	"github.com/diki-haryadi/ztools/env"
+)
+
+const (
+	ExtProtocolGrpc = "grpc"
+	ExtProtocolHttp = "http"
+
+	extPrefix = "EXT_"
+)
+
+// extHostKey discovers downstream services from keys such as EXT_PAYMENT_GRPC_HOST,
+// or EXT_PAYMENT_GRPC_HOST_FILE when the host is read from a file.
+var extHostKey = regexp.MustCompile(`^EXT_([A-Z0-9_]+)_(GRPC|HTTP)_HOST(?:_FILE)?$`)
+
+// ExternalServiceConfig is a downstream service declared through env keys prefixed
+// with EXT_<NAME>_GRPC_ or EXT_<NAME>_HTTP_, e.g. EXT_PAYMENT_GRPC_HOST.
+type ExternalServiceConfig struct {
+	Name     string `env:"-"`
+	Protocol string `env:"-"`
+	Prefix   string `env:"-"`
+
+	Host    string        `env:"HOST" required:"true"`
+	Port    int           `env:"PORT"`
+	TLS     bool          `env:"TLS" default:"false"`
+	Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+	Retry   RetryConfig
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           `env:"RETRY_MAX_ATTEMPTS" default:"1"`
+	InitialBackoff time.Duration `env:"RETRY_INITIAL_BACKOFF" default:"100ms"`
+	MaxBackoff     time.Duration `env:"RETRY_MAX_BACKOFF" default:"1s"`
+	// NonIdempotent also retries POST and PATCH requests of an HTTP service; only
+	// enable it when the service deduplicates them.
+	NonIdempotent bool `env:"RETRY_NON_IDEMPOTENT" default:"false"`
+}
+
+func (ec ExternalServiceConfig) Validate() error {
+	portRules := []validator.Rule{validator.Min(0), validator.Max(65535)}
+	if ec.Protocol == ExtProtocolGrpc {
+		portRules = append([]validator.Rule{validator.Required}, portRules...)
+	}
+
+	return validator.ValidateStruct(&ec,
+		validator.Field(&ec.Host, validator.Required),
+		validator.Field(&ec.Port, portRules...),
+		validator.Field(&ec.Timeout, validator.Min(time.Duration(0))),
+		validator.Field(&ec.Retry),
+	)
+}
+
+func (rc RetryConfig) Validate() error {
+	return validator.ValidateStruct(&rc,
+		validator.Field(&rc.MaxAttempts, validator.Min(1)),
+		validator.Field(&rc.InitialBackoff, validator.Min(time.Duration(0))),
+		validator.Field(&rc.MaxBackoff, validator.Min(rc.InitialBackoff)),
+	)
+}
+
+// ExternalServiceKey is the key of a service in Config.ExternalServices, e.g.
+// "payment/grpc" for EXT_PAYMENT_GRPC_HOST, so one name can have both protocols.
+func ExternalServiceKey(name string, protocol string) string {
+	return name + "/" + protocol
+}
+
+// ExternalService returns the service declared as name for protocol.
+func (c *Config) ExternalService(name string, protocol string) (ExternalServiceConfig, bool) {
+	svc, ok := c.ExternalServices[ExternalServiceKey(name, protocol)]
+	return svc, ok
+}
+
+// loadExternalServices loads every service declared in layers. Only sources that can
+// list their keys, i.e. not custom ones without env.Lister, can declare a service;
+// its other keys may come from any source.
+func loadExternalServices(layers *env.Layers) (map[string]ExternalServiceConfig, error) {
+	services := map[string]ExternalServiceConfig{}
+	var errs env.Errors
+
+	for _, key := range layers.Keys() {
+		match := extHostKey.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		name, protocol := strings.ToLower(match[1]), strings.ToLower(match[2])
+		if _, ok := services[ExternalServiceKey(name, protocol)]; ok {
+			continue
+		}
+
+		svc := ExternalServiceConfig{
+			Name:     name,
+			Protocol: protocol,
+			Prefix:   extPrefix + match[1] + "_" + match[2] + "_",
+		}
+		if err := layers.LoadWithPrefix(svc.Prefix, &svc); err != nil {
+			if es, ok := err.(env.Errors); ok {
+				errs = append(errs, es...)
+				continue
+			}
+			return nil, err
+		}
+		services[ExternalServiceKey(name, protocol)] = svc
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return services, nil
+}
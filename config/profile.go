@@ -0,0 +1,101 @@
+package config
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/diki-haryadi/ztools/constant"
+	"github.com/diki-haryadi/ztools/env"
+)
+
+// Profile describes how an environment (APP_ENV) behaves. Subsystems decide on these
+// attributes instead of comparing APP_ENV with constant.AppEnvDev.
+type Profile struct {
+	Name string
+	// Development enables developer conveniences such as the echo banner.
+	Development bool
+	// ConsoleLogs writes human-readable logs to stdout instead of JSON log files.
+	ConsoleLogs            bool
+	GrpcReflection         bool
+	SentryTracing          bool
+	SentryTracesSampleRate float64
+	// Defaults override the default tags of the configuration for this profile, keyed
+	// by env name (PG_MAX_CONNECTIONS). Any other source still takes precedence.
+	Defaults map[string]string
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]Profile{
+		constant.AppEnvDev: {
+			Name:                   constant.AppEnvDev,
+			Development:            true,
+			ConsoleLogs:            true,
+			GrpcReflection:         true,
+			SentryTracing:          true,
+			SentryTracesSampleRate: 1.0,
+		},
+		constant.AppEnvTest: {
+			Name:                   constant.AppEnvTest,
+			ConsoleLogs:            true,
+			SentryTracesSampleRate: 1.0,
+		},
+		constant.AppEnvProd: {
+			Name:                   constant.AppEnvProd,
+			SentryTracesSampleRate: 1.0,
+		},
+	}
+)
+
+// RegisterProfile adds a profile such as staging or canary, or replaces a built-in one.
+func RegisterProfile(p Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[p.Name] = p
+}
+
+func GetProfile(name string) (Profile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	p, ok := profiles[name]
+
+	return p, ok
+}
+
+func ProfileNames() []string {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Profile returns the profile selected by App.AppEnv. Validate rejects unknown
+// names, so the zero Profile only shows up for configurations built by hand.
+func (c *Config) Profile() Profile {
+	p, _ := GetProfile(c.App.AppEnv)
+	return p
+}
+
+func CurrentProfile() Profile {
+	return Current().Profile()
+}
+
+// applyProfileDefaults resolves APP_ENV and installs the defaults of its profile as
+// the base source of layers.
+func applyProfileDefaults(layers *env.Layers) {
+	layers.SetBase(nil)
+
+	appEnv, err := env.New("APP_ENV", constant.AppEnvDev).In(layers).TryString()
+	if err != nil {
+		return
+	}
+	if p, ok := GetProfile(appEnv); ok && len(p.Defaults) > 0 {
+		layers.SetBase(env.NewMapSource("profile:"+p.Name, p.Defaults))
+	}
+}
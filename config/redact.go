@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/diki-haryadi/ztools/env"
+)
+
+// Every type holding a `secret:"true"` field redacts it when printed with fmt,
+// marshaled to JSON or logged with zap.Any.
+
+type (
+	configView         Config
+	postgresConfigView PostgresConfig
+	redisConfigView    RedisConfig
+	sentryConfigView   SentryConfig
+	auditConfigView    AuditConfig
+)
+
+func (c Config) String() string {
+	return fmt.Sprintf("%+v", configView(env.Redact(c)))
+}
+
+func (c Config) GoString() string {
+	return c.String()
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configView(env.Redact(c)))
+}
+
+func (pc PostgresConfig) String() string {
+	return fmt.Sprintf("%+v", postgresConfigView(env.Redact(pc)))
+}
+
+func (pc PostgresConfig) GoString() string {
+	return pc.String()
+}
+
+func (pc PostgresConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(postgresConfigView(env.Redact(pc)))
+}
+
+func (rc RedisConfig) String() string {
+	return fmt.Sprintf("%+v", redisConfigView(env.Redact(rc)))
+}
+
+func (rc RedisConfig) GoString() string {
+	return rc.String()
+}
+
+func (rc RedisConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redisConfigView(env.Redact(rc)))
+}
+
+func (sc SentryConfig) String() string {
+	return fmt.Sprintf("%+v", sentryConfigView(env.Redact(sc)))
+}
+
+func (sc SentryConfig) GoString() string {
+	return sc.String()
+}
+
+func (sc SentryConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sentryConfigView(env.Redact(sc)))
+}
+
+func (ac AuditConfig) String() string {
+	return fmt.Sprintf("%+v", auditConfigView(env.Redact(ac)))
+}
+
+func (ac AuditConfig) GoString() string {
+	return ac.String()
+}
+
+func (ac AuditConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(auditConfigView(env.Redact(ac)))
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	validator "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/diki-haryadi/ztools/constant"
+)
+
+// PgSslModes are the sslmode values accepted by libpq.
+var PgSslModes = []interface{}{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+var samplingRule = regexp.MustCompile(`^(?i:off|\d+:\d+)$`)
+
+var RedisModes = []interface{}{constant.RedisModeStandalone, constant.RedisModeSentinel, constant.RedisModeCluster}
+
+var LogSinks = []interface{}{constant.LogSinkConsole, constant.LogSinkStdout, constant.LogSinkStderr, constant.LogSinkFile}
+
+var CronHistories = []interface{}{constant.CronHistoryMemory, constant.CronHistoryPostgres}
+
+var CronPauses = []interface{}{constant.CronPausesMemory, constant.CronPausesRedis, constant.CronPausesPostgres}
+
+var AuditSinks = []interface{}{constant.AuditSinkFile, constant.AuditSinkKafka}
+
+var RateLimitAlgorithms = []interface{}{constant.RateLimitSlidingWindow, constant.RateLimitTokenBucket}
+
+var LogSchemas = []interface{}{constant.LogSchemaBracketed, constant.LogSchemaECS, constant.LogSchemaOTel, constant.LogSchemaGCP}
+
+// Validate checks that the configuration is coherent and returns every problem at
+// once as a validation.Errors keyed by section and field name.
+func (c Config) Validate() error {
+	return validator.ValidateStruct(&c,
+		validator.Field(&c.App),
+		validator.Field(&c.Grpc),
+		validator.Field(&c.Http),
+		validator.Field(&c.Postgres),
+		validator.Field(&c.Redis),
+		validator.Field(&c.ExternalServices),
+		validator.Field(&c.Kafka),
+		validator.Field(&c.Sentry),
+		validator.Field(&c.Log),
+		validator.Field(&c.Audit),
+		validator.Field(&c.Cron),
+		validator.Field(&c.RateLimit),
+	)
+}
+
+func (ac AppConfig) Validate() error {
+	names := ProfileNames()
+	profileNames := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		profileNames = append(profileNames, name)
+	}
+
+	return validator.ValidateStruct(&ac,
+		validator.Field(&ac.AppEnv, validator.Required, validator.In(profileNames...).Error("must be a registered profile")),
+		validator.Field(&ac.AppName, validator.Required),
+	)
+}
+
+func (gc GrpcConfig) Validate() error {
+	return validator.ValidateStruct(&gc,
+		validator.Field(&gc.Port, validator.Required, validator.Min(1), validator.Max(65535)),
+		validator.Field(&gc.Host, validator.Required),
+	)
+}
+
+func (hc HttpConfig) Validate() error {
+	return validator.ValidateStruct(&hc,
+		validator.Field(&hc.Port, validator.Required, validator.Min(1), validator.Max(65535)),
+		validator.Field(&hc.Host, validator.Required),
+		validator.Field(&hc.ReadTimeout, validator.Min(time.Duration(0))),
+		validator.Field(&hc.WriteTimeout, validator.Min(time.Duration(0))),
+		validator.Field(&hc.IdleTimeout, validator.Min(time.Duration(0))),
+	)
+}
+
+func (pc PostgresConfig) Validate() error {
+	maxIdleConnRules := []validator.Rule{validator.Min(0)}
+	if pc.MaxConn > 0 {
+		maxIdleConnRules = append(maxIdleConnRules, validator.Max(pc.MaxConn).Error("must be no greater than MaxConn"))
+	}
+
+	return validator.ValidateStruct(&pc,
+		validator.Field(&pc.Host, validator.Required),
+		validator.Field(&pc.Port, validator.Required, is.Port),
+		validator.Field(&pc.User, validator.Required),
+		validator.Field(&pc.DBName, validator.Required),
+		validator.Field(&pc.MaxConn, validator.Min(0)),
+		validator.Field(&pc.MaxIdleConn, maxIdleConnRules...),
+		validator.Field(&pc.MaxLifeTimeConn, validator.Min(0)),
+		validator.Field(&pc.SslMode, validator.Required, validator.In(PgSslModes...)),
+	)
+}
+
+func (rc RedisConfig) Validate() error {
+	var masterNameRules, dbRules []validator.Rule
+	minIdleConnsRules := []validator.Rule{validator.Min(0)}
+	if rc.PoolSize > 0 {
+		minIdleConnsRules = append(minIdleConnsRules, validator.Max(rc.PoolSize).Error("must be no greater than PoolSize"))
+	}
+	switch rc.Mode {
+	case constant.RedisModeSentinel:
+		masterNameRules = append(masterNameRules, validator.Required)
+	case constant.RedisModeCluster:
+		dbRules = append(dbRules, validator.In(0).Error("must be 0 in cluster mode"))
+	}
+
+	return validator.ValidateStruct(&rc,
+		validator.Field(&rc.Mode, validator.Required, validator.In(RedisModes...)),
+		validator.Field(&rc.Addrs, validator.Required, validator.Each(validator.Required)),
+		validator.Field(&rc.MasterName, masterNameRules...),
+		validator.Field(&rc.DB, append(dbRules, validator.Min(0))...),
+		validator.Field(&rc.PoolSize, validator.Min(0)),
+		validator.Field(&rc.MinIdleConns, minIdleConnsRules...),
+		validator.Field(&rc.MaxRetries, validator.Min(0)),
+		validator.Field(&rc.MinRetryBackoff, validator.Min(time.Duration(0))),
+		validator.Field(&rc.MaxRetryBackoff, validator.Min(rc.MinRetryBackoff).Error("must be no less than MinRetryBackoff")),
+	)
+}
+
+func (kc KafkaConfig) Validate() error {
+	if !kc.Enabled {
+		return nil
+	}
+
+	return validator.ValidateStruct(&kc,
+		validator.Field(&kc.ClientBrokers, validator.Required, validator.Each(validator.Required)),
+		validator.Field(&kc.Topic, validator.Required),
+		validator.Field(&kc.ClientGroupId, validator.Required),
+	)
+}
+
+func (sc SentryConfig) Validate() error {
+	return validator.ValidateStruct(&sc,
+		validator.Field(&sc.Dsn, is.URL),
+	)
+}
+
+func (lc LogConfig) Validate() error {
+	var gcpProjectRules []validator.Rule
+	if lc.Schema == constant.LogSchemaGCP {
+		gcpProjectRules = append(gcpProjectRules, validator.Required)
+	}
+
+	return validator.ValidateStruct(&lc,
+		validator.Field(&lc.Level, validator.Required, validator.By(validLogLevel)),
+		validator.Field(&lc.Levels, validator.Each(validator.By(validLogLevel))),
+		validator.Field(&lc.Sampling, validator.Required, validator.Match(samplingRule).Error("must be N:M or off")),
+		validator.Field(&lc.SampleTick, validator.Min(time.Millisecond)),
+		validator.Field(&lc.SampleRoutes, validator.Each(validator.Match(samplingRule).Error("must be N:M or off"))),
+		validator.Field(&lc.Sinks, validator.Each(validator.In(LogSinks...))),
+		validator.Field(&lc.Schema, validator.Required, validator.In(LogSchemas...)),
+		validator.Field(&lc.GCPProject, gcpProjectRules...),
+		validator.Field(&lc.MaxBackups, validator.Min(0)),
+		validator.Field(&lc.MaxAge, validator.Min(time.Duration(0))),
+		validator.Field(&lc.RotateInterval, validator.Min(time.Duration(0))),
+	)
+}
+
+func (cc CronConfig) Validate() error {
+	return validator.ValidateStruct(&cc,
+		validator.Field(&cc.History, validator.Required, validator.In(CronHistories...)),
+		validator.Field(&cc.HistorySize, validator.Min(1)),
+		validator.Field(&cc.HistoryTable, validator.Required),
+		validator.Field(&cc.Pauses, validator.Required, validator.In(CronPauses...)),
+		validator.Field(&cc.PauseTable, validator.Required),
+	)
+}
+
+func (rc RateLimitConfig) Validate() error {
+	if !rc.Enabled {
+		return nil
+	}
+
+	return validator.ValidateStruct(&rc,
+		validator.Field(&rc.Algorithm, validator.Required, validator.In(RateLimitAlgorithms...)),
+		validator.Field(&rc.Rate, validator.Required, validator.Min(1)),
+		validator.Field(&rc.Period, validator.Required, validator.Min(time.Millisecond)),
+		validator.Field(&rc.Burst, validator.Min(0)),
+		validator.Field(&rc.TrustedProxies, validator.Each(validator.By(validTrustedProxy))),
+		validator.Field(&rc.RedisTimeout, validator.Min(time.Millisecond)),
+		validator.Field(&rc.RedisCooldown, validator.Min(time.Millisecond)),
+	)
+}
+
+func (ac AuditConfig) Validate() error {
+	if !ac.Enabled {
+		return nil
+	}
+
+	var topicRules []validator.Rule
+	if ac.Sink == constant.AuditSinkKafka {
+		topicRules = append(topicRules, validator.Required)
+	}
+
+	return validator.ValidateStruct(&ac,
+		validator.Field(&ac.Sink, validator.Required, validator.In(AuditSinks...)),
+		validator.Field(&ac.Topic, topicRules...),
+	)
+}
+
+func validTrustedProxy(value interface{}) error {
+	proxy, _ := value.(string)
+	proxy = strings.TrimSpace(proxy)
+	if proxy == "" {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+		return errors.New("must be a CIDR or an IP")
+	}
+
+	return nil
+}
+
+func validLogLevel(value interface{}) error {
+	level, _ := value.(string)
+	if _, err := zapcore.ParseLevel(level); err != nil {
+		return errors.New("must be a valid log level")
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/diki-haryadi/ztools/env"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+var current atomic.Pointer[Config]
+
+// Current returns the latest configuration snapshot. Unlike BaseConfig it follows the
+// reloads of a Watcher and is safe to read while one swaps in a new snapshot.
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+
+	return BaseConfig
+}
+
+type WatcherOptions struct {
+	// Files are polled for modification; usually the config file and .env files.
+	Files        []string
+	PollInterval time.Duration
+	// Layers rebuilds the env sources on every reload so file contents are re-read,
+	// e.g. func() (*env.Layers, error) { return env.NewStandardLayers(opts) }.
+	// When nil the current layers are reused.
+	Layers func() (*env.Layers, error)
+	// Validate adds checks on top of Config.Validate; a rejected snapshot keeps the
+	// previous one active.
+	Validate func(c *Config) error
+	// OnError receives reload failures; they are logged when nil.
+	OnError func(err error)
+}
+
+// Watcher reloads the configuration on SIGHUP or when one of the watched files
+// changes, and notifies subscribers once the new snapshot is swapped in.
+type Watcher struct {
+	opts     WatcherOptions
+	reloadMu sync.Mutex
+
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]func(old *Config, new *Config)
+	modTimes    map[string]time.Time
+}
+
+func NewWatcher(opts WatcherOptions) *Watcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			log.Printf("config reload failed: %v", err)
+		}
+	}
+
+	w := &Watcher{
+		opts:        opts,
+		subscribers: map[int]func(old *Config, new *Config){},
+		modTimes:    map[string]time.Time{},
+	}
+	w.filesChanged()
+
+	return w
+}
+
+// Start watches until ctx is done.
+func (w *Watcher) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		ticker := time.NewTicker(w.opts.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				w.reloadOrReport()
+			case <-ticker.C:
+				if w.filesChanged() {
+					w.reloadOrReport()
+				}
+			}
+		}
+	}()
+}
+
+func (w *Watcher) reloadOrReport() {
+	if err := w.Reload(); err != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// Reload re-reads every source, validates the result and only then swaps in the new
+// layers and snapshot, so a rejected reload is never visible to env or Current.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	layers := env.CurrentLayers()
+	if w.opts.Layers != nil {
+		var err error
+		if layers, err = w.opts.Layers(); err != nil {
+			return err
+		}
+	}
+
+	next, err := load(layers)
+	if err == nil && w.opts.Validate != nil {
+		err = w.opts.Validate(next)
+	}
+	if err != nil {
+		return err
+	}
+
+	prev := Current()
+	env.SetLayers(layers)
+	current.Store(next)
+
+	w.mu.Lock()
+	subscribers := make([]func(old *Config, new *Config), 0, len(w.subscribers))
+	for _, fn := range w.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(prev, next)
+	}
+
+	return nil
+}
+
+// Subscribe calls fn after every successful reload. The returned function removes
+// the subscription.
+func (w *Watcher) Subscribe(fn func(old *Config, new *Config)) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// SubscribeSection calls fn only when the section selected by section changed.
+func SubscribeSection[T any](w *Watcher, section func(c *Config) T, fn func(old T, new T)) func() {
+	return w.Subscribe(func(old *Config, new *Config) {
+		var prev T
+		if old != nil {
+			prev = section(old)
+		}
+		next := section(new)
+		if !reflect.DeepEqual(prev, next) {
+			fn(prev, next)
+		}
+	})
+}
+
+func (w *Watcher) OnAppChange(fn func(old AppConfig, new AppConfig)) func() {
+	return SubscribeSection(w, func(c *Config) AppConfig { return c.App }, fn)
+}
+
+func (w *Watcher) OnHttpChange(fn func(old HttpConfig, new HttpConfig)) func() {
+	return SubscribeSection(w, func(c *Config) HttpConfig { return c.Http }, fn)
+}
+
+func (w *Watcher) OnGrpcChange(fn func(old GrpcConfig, new GrpcConfig)) func() {
+	return SubscribeSection(w, func(c *Config) GrpcConfig { return c.Grpc }, fn)
+}
+
+func (w *Watcher) OnPostgresChange(fn func(old PostgresConfig, new PostgresConfig)) func() {
+	return SubscribeSection(w, func(c *Config) PostgresConfig { return c.Postgres }, fn)
+}
+
+func (w *Watcher) OnKafkaChange(fn func(old KafkaConfig, new KafkaConfig)) func() {
+	return SubscribeSection(w, func(c *Config) KafkaConfig { return c.Kafka }, fn)
+}
+
+func (w *Watcher) OnSentryChange(fn func(old SentryConfig, new SentryConfig)) func() {
+	return SubscribeSection(w, func(c *Config) SentryConfig { return c.Sentry }, fn)
+}
+
+func (w *Watcher) OnLogChange(fn func(old LogConfig, new LogConfig)) func() {
+	return SubscribeSection(w, func(c *Config) LogConfig { return c.Log }, fn)
+}
+
+func (w *Watcher) filesChanged() bool {
+	changed := false
+	for _, f := range w.opts.Files {
+		var modTime time.Time
+		if info, err := os.Stat(f); err == nil {
+			modTime = info.ModTime()
+		}
+		if prev, ok := w.modTimes[f]; !ok || !prev.Equal(modTime) {
+			w.modTimes[f] = modTime
+			changed = changed || ok
+		}
+	}
+
+	return changed
+}
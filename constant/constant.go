@@ -1,8 +1,11 @@
 package constant
 
-// App
-const AppName = "Go-Microservice-Template"
+import "time"
+
+// The defaults of the configuration live in the default tags of config.Config only,
+// they are not repeated here.
 
+// App
 const (
 	AppEnvProd = "prod"
 	AppEnvDev  = "dev"
@@ -10,19 +13,60 @@ const (
 )
 
 // Http + Grpc
+const EchoGzipLevel = 5
+
+// Redis
 const (
-	HttpHost      = "localhost"
-	HttpPort      = 4000
-	EchoGzipLevel = 5
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
 
-	GrpcHost = "localhost"
-	GrpcPort = 3000
+// Logger
+const (
+	LogSinkConsole = "console"
+	LogSinkStdout  = "stdout"
+	LogSinkStderr  = "stderr"
+	LogSinkFile    = "file"
+
+	// LogDir and LogFile are the defaults of LOG_DIR and LOG_FILE.
+	LogDir  = "tmp/logs"
+	LogFile = "main.log"
+
+	LogSchemaBracketed = "bracketed"
+	LogSchemaECS       = "ecs"
+	LogSchemaOTel      = "otel"
+	LogSchemaGCP       = "gcp"
 )
 
-// Postgres
+// Cron
 const (
-	PgMaxConn         = 1
-	PgMaxIdleConn     = 1
-	PgMaxLifeTimeConn = 1
-	PgSslMode         = "disable"
+	CronHistoryMemory   = "memory"
+	CronHistoryPostgres = "postgres"
+
+	CronPausesMemory   = "memory"
+	CronPausesRedis    = "redis"
+	CronPausesPostgres = "postgres"
+)
+
+// Rate limit
+const (
+	RateLimitSlidingWindow = "sliding_window"
+	RateLimitTokenBucket   = "token_bucket"
+)
+
+// Audit
+const (
+	AuditSinkFile  = "file"
+	AuditSinkKafka = "kafka"
+
+	// AuditDir and AuditFile are the defaults of AUDIT_DIR and AUDIT_FILE.
+	AuditDir  = "tmp/audit"
+	AuditFile = "audit.log"
+
+	AuditKafkaQueueSize = 1024
+	AuditKafkaBatchSize = 100
+	// A batch that fails is retried with a doubling backoff before it is dropped.
+	AuditKafkaAttempts     = 5
+	AuditKafkaRetryBackoff = 200 * time.Millisecond
 )
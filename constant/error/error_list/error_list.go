@@ -6,6 +6,7 @@ type internalErrorList struct {
 	ValidationError     ErrorList
 	InternalServerError ErrorList
 	NotFoundError       ErrorList
+	TooManyRequests     ErrorList
 	ArticleExceptions   ArticleErrorList
 }
 
@@ -43,6 +44,11 @@ func init() {
 			Code: 1002,
 		},
 
+		TooManyRequests: ErrorList{
+			Msg:  "too many requests",
+			Code: 1003,
+		},
+
 		ArticleExceptions: ArticleErrorList{
 			BindingError: ErrorList{
 				Msg:  "binding failed",
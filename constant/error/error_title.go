@@ -7,6 +7,7 @@ const (
 	ErrUnauthorizedTitle        = "Unauthorized"
 	ErrForbiddenTitle           = "Forbidden"
 	ErrRequestTimeoutTitle      = "Request Timeout"
+	ErrTooManyRequestsTitle     = "Too Many Requests"
 	ErrInternalServerErrorTitle = "Internal Server Error"
 	ErrDomainTitle              = "Domain Model Error"
 	ErrApplicationTitle         = "Application Service Error"
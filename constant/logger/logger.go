@@ -15,6 +15,8 @@ const (
 	STACK_TRACE = "STACK_TRACE"
 	CODE        = "CODE"
 	STATUS      = "STATUS"
+	// STATUS_CODE is the numeric HTTP status of a response; STATUS holds its text.
+	STATUS_CODE = "STATUS_CODE"
 	MSG         = "MSG"
 	DETAILS     = "DETAILS"
 	ERR         = "ERR"
@@ -22,4 +24,13 @@ const (
 	REQUEST_ID  = "REQUEST_ID"
 	URI         = "URI"
 	LATENCY     = "LATENCY"
+	TRACE_ID    = "TRACE_ID"
+	SPAN_ID     = "SPAN_ID"
+	TOPIC       = "TOPIC"
+	PARTITION   = "PARTITION"
+	OFFSET      = "OFFSET"
+	ROUTE       = "ROUTE"
+	COUNT       = "COUNT"
+	// GRPC_METHOD is the full gRPC method; it is written as METHOD by the bracketed schema.
+	GRPC_METHOD = "GRPC_METHOD"
 )
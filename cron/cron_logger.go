@@ -1,7 +1,12 @@
 package cronJob
 
-import "github.com/diki-haryadi/ztools/logger"
+import (
+	"go.uber.org/zap"
 
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// CronLogger is the cron.Logger of the scheduler, writing to the "cron" named logger.
 type CronLogger struct{}
 
 func NewLogger() *CronLogger {
@@ -9,9 +14,9 @@ func NewLogger() *CronLogger {
 }
 
 func (l *CronLogger) Info(msg string, keysAndValues ...interface{}) {
-	logger.Zap.Sugar().Infow(msg, keysAndValues)
+	logger.Zap.Named(loggerName).Sugar().Infow(msg, keysAndValues...)
 }
 
 func (l *CronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
-	logger.Zap.Sugar().Errorw(msg, keysAndValues)
+	logger.Zap.Named(loggerName).Sugar().With(zap.Error(err)).Errorw(msg, keysAndValues...)
 }
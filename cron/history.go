@@ -0,0 +1,174 @@
+package cronJob
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/diki-haryadi/ztools/postgres"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// RunRecord is the outcome of one run of a job.
+type RunRecord struct {
+	ID         string        `json:"id" db:"id"`
+	Job        string        `json:"job" db:"job"`
+	Trigger    string        `json:"trigger" db:"trigger"`
+	StartedAt  time.Time     `json:"started_at" db:"started_at"`
+	FinishedAt time.Time     `json:"finished_at" db:"finished_at"`
+	Duration   time.Duration `json:"duration" db:"-"`
+	Status     string        `json:"status" db:"status"`
+	Error      string        `json:"error,omitempty" db:"error"`
+}
+
+// HistoryStore persists run records for the cron admin routes.
+type HistoryStore interface {
+	Save(ctx context.Context, rec RunRecord) error
+	// List returns the latest records of job, or of every job when job is empty,
+	// newest first.
+	List(ctx context.Context, job string, limit int) ([]RunRecord, error)
+}
+
+// MemoryHistory keeps the last records of each job in memory.
+type MemoryHistory struct {
+	mu      sync.RWMutex
+	max     int
+	records map[string][]RunRecord
+}
+
+// NewMemoryHistory keeps up to maxPerJob records per job, 100 when not positive.
+func NewMemoryHistory(maxPerJob int) *MemoryHistory {
+	if maxPerJob <= 0 {
+		maxPerJob = 100
+	}
+
+	return &MemoryHistory{max: maxPerJob, records: map[string][]RunRecord{}}
+}
+
+func (h *MemoryHistory) Save(_ context.Context, rec RunRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := append(h.records[rec.Job], rec)
+	if len(records) > h.max {
+		records = append([]RunRecord(nil), records[len(records)-h.max:]...)
+	}
+	h.records[rec.Job] = records
+
+	return nil
+}
+
+func (h *MemoryHistory) List(_ context.Context, job string, limit int) ([]RunRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []RunRecord
+	if job != "" {
+		out = append(out, h.records[job]...)
+	} else {
+		for _, records := range h.records {
+			out = append(out, records...)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].StartedAt.After(out[j].StartedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+
+	return out, nil
+}
+
+var tableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// PostgresHistory stores run records in a table shared by every replica.
+type PostgresHistory struct {
+	db    *postgres.Postgres
+	table string
+}
+
+func NewPostgresHistory(db *postgres.Postgres, table string) (*PostgresHistory, error) {
+	if !tableName.MatchString(table) {
+		return nil, errors.Errorf("cron: invalid history table name %q", table)
+	}
+
+	return &PostgresHistory{db: db, table: table}, nil
+}
+
+// Migrate creates the table and its index when they do not exist.
+func (h *PostgresHistory) Migrate(ctx context.Context) error {
+	_, err := h.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s (
+	id          TEXT PRIMARY KEY,
+	job         TEXT NOT NULL,
+	trigger     TEXT NOT NULL,
+	started_at  TIMESTAMPTZ NOT NULL,
+	finished_at TIMESTAMPTZ NOT NULL,
+	duration_ms BIGINT NOT NULL,
+	status      TEXT NOT NULL,
+	error       TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS %[2]s_job_started_at_idx ON %[1]s (job, started_at DESC);`,
+		h.table, indexPrefix(h.table),
+	))
+
+	return err
+}
+
+func (h *PostgresHistory) Save(ctx context.Context, rec RunRecord) error {
+	_, err := h.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO %s (id, job, trigger, started_at, finished_at, duration_ms, status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, h.table),
+		rec.ID, rec.Job, rec.Trigger, rec.StartedAt, rec.FinishedAt, rec.Duration.Milliseconds(), rec.Status, rec.Error,
+	)
+
+	return err
+}
+
+func (h *PostgresHistory) List(ctx context.Context, job string, limit int) ([]RunRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	type row struct {
+		RunRecord
+		DurationMs int64 `db:"duration_ms"`
+	}
+
+	var rows []row
+	err := h.db.SqlxDB.SelectContext(ctx, &rows, fmt.Sprintf(
+		`SELECT id, job, trigger, started_at, finished_at, duration_ms, status, error FROM %s
+WHERE ($1 = '' OR job = $1) ORDER BY started_at DESC LIMIT $2`, h.table),
+		job, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]RunRecord, len(rows))
+	for i, r := range rows {
+		out[i] = r.RunRecord
+		out[i].Duration = time.Duration(r.DurationMs) * time.Millisecond
+	}
+
+	return out, nil
+}
+
+func indexPrefix(table string) string {
+	for i := len(table) - 1; i >= 0; i-- {
+		if table[i] == '.' {
+			return table[i+1:]
+		}
+	}
+
+	return table
+}
@@ -0,0 +1,174 @@
+package cronJob
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/diki-haryadi/ztools/postgres"
+)
+
+// ErrLockLost is returned by Lease.Refresh when the lock expired or was taken over.
+var ErrLockLost = errors.New("cron: lock lost")
+
+// Locker hands out distributed locks so a job runs on a single replica per schedule.
+type Locker interface {
+	// TryLock returns a lease on key, or false when another replica holds it.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, bool, error)
+}
+
+type Lease interface {
+	// Refresh extends the lease by ttl.
+	Refresh(ctx context.Context, ttl time.Duration) error
+	Release(ctx context.Context) error
+}
+
+var (
+	redisRefreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	redisReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RedisLocker locks with SET NX PX and a random token, so a lease only refreshes or
+// releases a key it still owns.
+type RedisLocker struct {
+	client redis.UniversalClient
+}
+
+// NewRedisLocker takes the client of redis.NewUniversalRedisClient.
+func NewRedisLocker(client redis.UniversalClient) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, bool, error) {
+	token := newID()
+	ok, err := l.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	return &redisLease{client: l.client, key: key, token: token}, true, nil
+}
+
+type redisLease struct {
+	client redis.UniversalClient
+	key    string
+	token  string
+}
+
+func (rl *redisLease) Refresh(ctx context.Context, ttl time.Duration) error {
+	n, err := redisRefreshScript.Run(ctx, rl.client, []string{rl.key}, rl.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.Wrap(ErrLockLost, rl.key)
+	}
+
+	return nil
+}
+
+func (rl *redisLease) Release(ctx context.Context) error {
+	return redisReleaseScript.Run(ctx, rl.client, []string{rl.key}, rl.token).Err()
+}
+
+// PostgresLocker stores a row per lock in table with the token of its owner and an
+// expiry, so it holds no connection while a job runs. Only a row that expired can be
+// taken over, and the times come from the database clock.
+type PostgresLocker struct {
+	db    *postgres.Postgres
+	table string
+}
+
+func NewPostgresLocker(db *postgres.Postgres, table string) (*PostgresLocker, error) {
+	if !tableName.MatchString(table) {
+		return nil, errors.Errorf("cron: invalid lock table name %q", table)
+	}
+
+	return &PostgresLocker{db: db, table: table}, nil
+}
+
+// Migrate creates the table when it does not exist.
+func (l *PostgresLocker) Migrate(ctx context.Context) error {
+	_, err := l.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	key        TEXT PRIMARY KEY,
+	token      TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);`, l.table))
+
+	return err
+}
+
+func (l *PostgresLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, bool, error) {
+	token := newID()
+	var owner string
+	err := l.db.SqlxDB.QueryRowContext(ctx, fmt.Sprintf(
+		`INSERT INTO %s AS l (key, token, expires_at) VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+ON CONFLICT (key) DO UPDATE SET token = EXCLUDED.token, expires_at = EXCLUDED.expires_at
+WHERE l.expires_at < now()
+RETURNING token`, l.table),
+		key, token, ttl.Milliseconds(),
+	).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &postgresLease{locker: l, key: key, token: token}, true, nil
+}
+
+type postgresLease struct {
+	locker *PostgresLocker
+	key    string
+	token  string
+}
+
+func (pl *postgresLease) Refresh(ctx context.Context, ttl time.Duration) error {
+	res, err := pl.locker.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(
+		`UPDATE %s SET expires_at = now() + $3 * interval '1 millisecond' WHERE key = $1 AND token = $2`,
+		pl.locker.table),
+		pl.key, pl.token, ttl.Milliseconds(),
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errors.Wrap(ErrLockLost, pl.key)
+	}
+
+	return nil
+}
+
+// Release deletes the row of the lease along with the rows left expired by replicas
+// that stopped before releasing theirs.
+func (pl *postgresLease) Release(ctx context.Context) error {
+	_, err := pl.locker.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE (key = $1 AND token = $2) OR expires_at < now()`, pl.locker.table),
+		pl.key, pl.token,
+	)
+
+	return err
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
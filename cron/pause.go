@@ -0,0 +1,125 @@
+package cronJob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/diki-haryadi/ztools/postgres"
+)
+
+// Scopes of a pause, as reported by JobInfo.PauseScope.
+const (
+	// PauseScopeReplica pauses the job on the replica that received the call only.
+	PauseScopeReplica = "replica"
+	// PauseScopeShared pauses the job on every replica sharing the PauseStore.
+	PauseScopeShared = "shared"
+)
+
+// PauseStore keeps the paused jobs. Replicas sharing a Redis or Postgres store agree
+// on them, and a pause survives restarts.
+type PauseStore interface {
+	SetPaused(ctx context.Context, job string, paused bool) error
+	Paused(ctx context.Context, job string) (bool, error)
+}
+
+// MemoryPauses keeps the paused jobs of this replica only, until it restarts.
+type MemoryPauses struct {
+	mu     sync.RWMutex
+	paused map[string]bool
+}
+
+func NewMemoryPauses() *MemoryPauses {
+	return &MemoryPauses{paused: map[string]bool{}}
+}
+
+func (p *MemoryPauses) SetPaused(_ context.Context, job string, paused bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if paused {
+		p.paused[job] = true
+	} else {
+		delete(p.paused, job)
+	}
+
+	return nil
+}
+
+func (p *MemoryPauses) Paused(_ context.Context, job string) (bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.paused[job], nil
+}
+
+// RedisPauses keeps the paused jobs in the Redis set key.
+type RedisPauses struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// NewRedisPauses takes the client of redis.NewUniversalRedisClient and a key
+// namespaced by application, e.g. "app:cron:paused".
+func NewRedisPauses(client redis.UniversalClient, key string) *RedisPauses {
+	return &RedisPauses{client: client, key: key}
+}
+
+func (p *RedisPauses) SetPaused(ctx context.Context, job string, paused bool) error {
+	if paused {
+		return p.client.SAdd(ctx, p.key, job).Err()
+	}
+
+	return p.client.SRem(ctx, p.key, job).Err()
+}
+
+func (p *RedisPauses) Paused(ctx context.Context, job string) (bool, error) {
+	return p.client.SIsMember(ctx, p.key, job).Result()
+}
+
+// PostgresPauses keeps a row per paused job in table.
+type PostgresPauses struct {
+	db    *postgres.Postgres
+	table string
+}
+
+func NewPostgresPauses(db *postgres.Postgres, table string) (*PostgresPauses, error) {
+	if !tableName.MatchString(table) {
+		return nil, errors.Errorf("cron: invalid pause table name %q", table)
+	}
+
+	return &PostgresPauses{db: db, table: table}, nil
+}
+
+// Migrate creates the table when it does not exist.
+func (p *PostgresPauses) Migrate(ctx context.Context) error {
+	_, err := p.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	job       TEXT PRIMARY KEY,
+	paused_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);`, p.table))
+
+	return err
+}
+
+func (p *PostgresPauses) SetPaused(ctx context.Context, job string, paused bool) error {
+	query := `DELETE FROM %s WHERE job = $1`
+	if paused {
+		query = `INSERT INTO %s (job) VALUES ($1) ON CONFLICT (job) DO NOTHING`
+	}
+	_, err := p.db.SqlxDB.ExecContext(ctx, fmt.Sprintf(query, p.table), job)
+
+	return err
+}
+
+func (p *PostgresPauses) Paused(ctx context.Context, job string) (bool, error) {
+	var paused bool
+	err := p.db.SqlxDB.QueryRowContext(ctx, fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE job = $1)`, p.table), job,
+	).Scan(&paused)
+
+	return paused, err
+}
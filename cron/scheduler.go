@@ -0,0 +1,668 @@
+package cronJob
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	"github.com/diki-haryadi/ztools/logger"
+	sentryUtils "github.com/diki-haryadi/ztools/sentry/sentry_utils"
+	"github.com/diki-haryadi/ztools/wrapper"
+	wrapperErrorhandler "github.com/diki-haryadi/ztools/wrapper/handlers/error_handler"
+	wrapperRecoveryhandler "github.com/diki-haryadi/ztools/wrapper/handlers/recovery_handler"
+	wrapperSentryhandler "github.com/diki-haryadi/ztools/wrapper/handlers/sentry_handler"
+)
+
+// loggerName lets LOG_LEVELS=cron=debug change the level of the scheduler logs only.
+const loggerName = "cron"
+
+// OverlapPolicy decides what happens when a job is due while its previous run is
+// still in progress.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip drops the new run.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue starts the new run once the previous one finished.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapAllow runs both concurrently.
+	OverlapAllow OverlapPolicy = "allow"
+)
+
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunTimedOut  = "timed_out"
+	RunPanicked  = "panicked"
+	RunSkipped   = "skipped"
+)
+
+var (
+	ErrJobExists   = errors.New("cron: job already registered")
+	ErrJobNotFound = errors.New("cron: job not found")
+	// ErrPanicked is the error of a run whose panic was recovered by the middleware chain.
+	ErrPanicked = errors.New("cron: job panicked")
+)
+
+type JobFunc func(ctx context.Context) error
+
+type Job struct {
+	Name string
+	// Spec is a cron expression or descriptor, e.g. "0 */5 * * *" or "@hourly".
+	// Every schedules the job at a fixed interval instead; exactly one must be set.
+	Spec  string
+	Every time.Duration
+	Run   JobFunc
+	// Timeout cancels the context of a run; zero disables it.
+	Timeout time.Duration
+	// Overlap defaults to OverlapSkip.
+	Overlap OverlapPolicy
+	// Jitter delays every run by a random duration up to Jitter.
+	Jitter time.Duration
+}
+
+type Options struct {
+	Location *time.Location
+	// Seconds accepts an optional leading seconds field in Spec.
+	Seconds bool
+	// Middlewares wrap every run, outermost first. Recovery, sentry and error
+	// handlers are used when nil.
+	Middlewares []wrapper.Middleware
+	// Sentry tags the events of the default sentry handler.
+	Sentry *sentryUtils.Options
+	// Locker makes every scheduled run take a lock on the job and its schedule time,
+	// so replicas sharing the Locker run it once; nil runs jobs on every replica.
+	Locker Locker
+	// LockTTL is the lease of a run, renewed while it runs and kept at least this long
+	// to cover clock skew between replicas. Defaults to 30s.
+	LockTTL time.Duration
+	// LockPrefix namespaces the lock keys, e.g. by application name.
+	LockPrefix string
+	// History records every run; nil keeps no history.
+	History HistoryStore
+	// Pauses keeps the paused jobs. Share a RedisPauses or PostgresPauses between the
+	// replicas sharing Locker; nil pauses on the replica that is called only.
+	Pauses PauseStore
+}
+
+const defaultLockTTL = 30 * time.Second
+
+// Scheduler runs registered jobs on their schedule through the wrapper middleware
+// chain and logs every run.
+type Scheduler struct {
+	cron        *cron.Cron
+	parser      cron.Parser
+	middlewares []wrapper.Middleware
+	locker      Locker
+	lockTTL     time.Duration
+	lockPrefix  string
+	history     HistoryStore
+	pauses      PauseStore
+	pauseScope  string
+
+	mu   sync.Mutex
+	jobs map[string]*entry
+	ctx  context.Context
+	stop context.CancelFunc
+	// manual tracks the runs started by Trigger, which robfig/cron does not know about.
+	manual sync.WaitGroup
+}
+
+type entry struct {
+	job     Job
+	id      cron.EntryID
+	running int32
+	active  int32
+	queue   sync.Mutex
+}
+
+// JobInfo describes a registered job for the admin routes.
+type JobInfo struct {
+	Name    string        `json:"name"`
+	Spec    string        `json:"spec,omitempty"`
+	Every   time.Duration `json:"every,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"`
+	Overlap OverlapPolicy `json:"overlap"`
+	Paused  bool          `json:"paused"`
+	// PauseScope tells whether Paused holds for every replica, see PauseScopeShared.
+	PauseScope string    `json:"pause_scope"`
+	Running    int       `json:"running"`
+	Next       time.Time `json:"next"`
+}
+
+func NewScheduler(opts Options) *Scheduler {
+	fields := cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
+	if opts.Seconds {
+		fields |= cron.SecondOptional
+	}
+	parser := cron.NewParser(fields)
+
+	cronOpts := []cron.Option{cron.WithParser(parser), cron.WithLogger(NewLogger())}
+	if opts.Location != nil {
+		cronOpts = append(cronOpts, cron.WithLocation(opts.Location))
+	}
+
+	middlewares := opts.Middlewares
+	if middlewares == nil {
+		sentryOpts := sentryUtils.Options{}
+		if opts.Sentry != nil {
+			sentryOpts = *opts.Sentry
+		}
+		// The recovery handler outside stops the panic re-raised after capturing it.
+		sentryOpts.Repanic = true
+		middlewares = []wrapper.Middleware{
+			wrapperRecoveryhandler.RecoveryHandler,
+			wrapperSentryhandler.NewSentryHandler(&sentryOpts),
+			wrapperErrorhandler.ErrorHandler,
+		}
+	}
+
+	lockTTL := opts.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = defaultLockTTL
+	}
+
+	pauses, pauseScope := opts.Pauses, PauseScopeShared
+	if pauses == nil {
+		pauses = NewMemoryPauses()
+	}
+	if _, ok := pauses.(*MemoryPauses); ok {
+		pauseScope = PauseScopeReplica
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		locker:      opts.Locker,
+		lockTTL:     lockTTL,
+		lockPrefix:  opts.LockPrefix,
+		history:     opts.History,
+		pauses:      pauses,
+		pauseScope:  pauseScope,
+		cron:        cron.New(cronOpts...),
+		parser:      parser,
+		middlewares: middlewares,
+		jobs:        map[string]*entry{},
+		ctx:         ctx,
+		stop:        cancel,
+	}
+}
+
+// Register schedules job; it can be called before or after Start.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("cron: job needs a name and a Run func")
+	}
+	if (job.Spec == "") == (job.Every <= 0) {
+		return errors.Errorf("cron: job %q needs exactly one of Spec and Every", job.Name)
+	}
+	if job.Overlap == "" {
+		job.Overlap = OverlapSkip
+	}
+	switch job.Overlap {
+	case OverlapSkip, OverlapQueue, OverlapAllow:
+	default:
+		return errors.Errorf("cron: job %q has unknown overlap policy %q", job.Name, job.Overlap)
+	}
+
+	var schedule cron.Schedule
+	if job.Spec != "" {
+		var err error
+		if schedule, err = s.parser.Parse(job.Spec); err != nil {
+			return errors.Wrapf(err, "cron: job %q", job.Name)
+		}
+	} else {
+		schedule = alignedEvery(job.Every)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[job.Name]; ok {
+		return errors.Wrap(ErrJobExists, job.Name)
+	}
+	e := &entry{job: job}
+	e.id = s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.dispatch(e, TriggerSchedule, s.scheduledAt(e))
+	}))
+	s.jobs[job.Name] = e
+
+	return nil
+}
+
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.jobs[name]
+	if !ok {
+		return errors.Wrap(ErrJobNotFound, name)
+	}
+	s.cron.Remove(e.id)
+	delete(s.jobs, name)
+
+	return nil
+}
+
+// Next returns the next scheduled run of a job.
+func (s *Scheduler) Next(name string) (time.Time, error) {
+	s.mu.Lock()
+	e, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return time.Time{}, errors.Wrap(ErrJobNotFound, name)
+	}
+
+	return s.cron.Entry(e.id).Next, nil
+}
+
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobInfo, error) {
+	s.mu.Lock()
+	entries := make([]*entry, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	// The pause store is read outside of mu, it may be remote.
+	infos := make([]JobInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := s.info(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos, nil
+}
+
+func (s *Scheduler) Job(ctx context.Context, name string) (JobInfo, error) {
+	e, err := s.entry(name)
+	if err != nil {
+		return JobInfo{}, err
+	}
+
+	return s.info(ctx, e)
+}
+
+func (s *Scheduler) info(ctx context.Context, e *entry) (JobInfo, error) {
+	paused, err := s.pauses.Paused(ctx, e.job.Name)
+	if err != nil {
+		return JobInfo{}, errors.Wrapf(err, "cron: job %q", e.job.Name)
+	}
+
+	return JobInfo{
+		Name:       e.job.Name,
+		Spec:       e.job.Spec,
+		Every:      e.job.Every,
+		Timeout:    e.job.Timeout,
+		Overlap:    e.job.Overlap,
+		Paused:     paused,
+		PauseScope: s.pauseScope,
+		Running:    int(atomic.LoadInt32(&e.active)),
+		Next:       s.cron.Entry(e.id).Next,
+	}, nil
+}
+
+// Pause stops the scheduled runs of a job until Resume, on every replica sharing
+// Options.Pauses or on this one only without it; a manual Trigger still runs it.
+func (s *Scheduler) Pause(ctx context.Context, name string) error {
+	return s.setPaused(ctx, name, true)
+}
+
+func (s *Scheduler) Resume(ctx context.Context, name string) error {
+	return s.setPaused(ctx, name, false)
+}
+
+func (s *Scheduler) setPaused(ctx context.Context, name string, paused bool) error {
+	if _, err := s.entry(name); err != nil {
+		return err
+	}
+
+	return s.pauses.SetPaused(ctx, name, paused)
+}
+
+// paused tells whether the scheduled runs of e are paused. A failing store is logged
+// and runs the job, so an outage of the store does not stop every job.
+func (s *Scheduler) paused(e *entry) bool {
+	paused, err := s.pauses.Paused(s.ctx, e.job.Name)
+	if err != nil {
+		logger.FromContext(s.ctx).Named(loggerName).Error("Job Pause State Failed",
+			zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+			zap.String(loggerConstant.NAME, e.job.Name),
+			zap.String(loggerConstant.ERR, err.Error()),
+		)
+		return false
+	}
+
+	return paused
+}
+
+// Trigger starts a run of a job now, outside of its schedule and without taking the
+// distributed lock. The overlap policy still applies.
+func (s *Scheduler) Trigger(name string) error {
+	e, err := s.entry(name)
+	if err != nil {
+		return err
+	}
+
+	// Stop cancels ctx under mu, so no run is added once it waits for them.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.Err(); err != nil {
+		return errors.Wrap(err, "cron: scheduler stopped")
+	}
+	s.manual.Add(1)
+	go func() {
+		defer s.manual.Done()
+		s.dispatch(e, TriggerManual, time.Now())
+	}()
+
+	return nil
+}
+
+// History lists the runs of a job, or of every job when name is empty, newest first.
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]RunRecord, error) {
+	if name != "" {
+		if _, err := s.entry(name); err != nil {
+			return nil, err
+		}
+	}
+	if s.history == nil {
+		return []RunRecord{}, nil
+	}
+
+	return s.history.List(ctx, name, limit)
+}
+
+func (s *Scheduler) entry(name string) (*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.jobs[name]
+	if !ok {
+		return nil, errors.Wrap(ErrJobNotFound, name)
+	}
+
+	return e, nil
+}
+
+// Start runs the scheduler until ctx is done or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.cron.Start()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+		case <-s.ctx.Done():
+		}
+	}()
+}
+
+// Stop cancels the context of running jobs and returns a context done once they,
+// including the runs started by Trigger, returned.
+func (s *Scheduler) Stop() context.Context {
+	s.mu.Lock()
+	s.stop()
+	s.mu.Unlock()
+
+	cronCtx := s.cron.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-cronCtx.Done()
+		s.manual.Wait()
+		cancel()
+	}()
+
+	return ctx
+}
+
+// scheduledAt returns the time the current run of e was scheduled at, which every
+// replica agrees on. robfig/cron sets Prev before it serves Entry again.
+func (s *Scheduler) scheduledAt(e *entry) time.Time {
+	s.mu.Lock()
+	id := e.id
+	s.mu.Unlock()
+
+	if prev := s.cron.Entry(id).Prev; !prev.IsZero() {
+		return prev
+	}
+	// The job was removed meanwhile.
+	return time.Now().Round(time.Second)
+}
+
+// dispatch runs e once; tick is its scheduled time and keys the distributed lock.
+func (s *Scheduler) dispatch(e *entry, trigger string, tick time.Time) {
+	if trigger == TriggerSchedule && s.paused(e) {
+		logger.FromContext(s.ctx).Named(loggerName).Debug(
+			"Job Paused",
+			zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+			zap.String(loggerConstant.NAME, e.job.Name),
+		)
+		return
+	}
+
+	switch e.job.Overlap {
+	case OverlapSkip:
+		if !atomic.CompareAndSwapInt32(&e.running, 0, 1) {
+			logger.FromContext(s.ctx).Named(loggerName).Info(
+				"Job Skipped",
+				zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+				zap.String(loggerConstant.NAME, e.job.Name),
+				zap.String(loggerConstant.STATUS, RunSkipped),
+			)
+			now := time.Now()
+			s.save(RunRecord{
+				ID:         newID(),
+				Job:        e.job.Name,
+				Trigger:    trigger,
+				StartedAt:  now,
+				FinishedAt: now,
+				Status:     RunSkipped,
+			})
+			return
+		}
+		defer atomic.StoreInt32(&e.running, 0)
+	case OverlapQueue:
+		e.queue.Lock()
+		defer e.queue.Unlock()
+	}
+
+	ctx := s.ctx
+	if s.locker != nil && trigger == TriggerSchedule {
+		lease, ok := s.lock(e.job.Name, tick)
+		if !ok {
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		defer s.keepLease(ctx, cancel, e.job.Name, lease)()
+	}
+
+	if e.job.Jitter > 0 {
+		t := time.NewTimer(time.Duration(rand.Int63n(int64(e.job.Jitter))))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+
+	atomic.AddInt32(&e.active, 1)
+	defer atomic.AddInt32(&e.active, -1)
+	s.run(ctx, e.job, trigger)
+}
+
+// run executes one run of job through the middlewares and logs its outcome.
+func (s *Scheduler) run(ctx context.Context, job Job, trigger string) (string, error) {
+	ctx = logger.WithContext(ctx, zap.String(loggerConstant.NAME, job.Name))
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	returned := false
+	handler := wrapper.BuildChain(func(ctx context.Context, _ ...interface{}) (interface{}, error) {
+		err := job.Run(ctx)
+		returned = true
+		return nil, err
+	}, s.middlewares...)
+
+	start := time.Now()
+	_, err := handler(ctx)
+	end := time.Now()
+	latency := end.Sub(start)
+
+	status := RunSucceeded
+	switch {
+	case !returned:
+		status, err = RunPanicked, ErrPanicked
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = RunTimedOut
+	case err != nil:
+		status = RunFailed
+	}
+
+	l := logger.FromContext(ctx).Named(loggerName)
+	fields := []zap.Field{
+		zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+		zap.String(loggerConstant.STATUS, status),
+		zap.Duration(loggerConstant.LATENCY, latency),
+		zap.Time(loggerConstant.TIME, start),
+	}
+	if err != nil {
+		l.Warn("Job Finished", append(fields, zap.String(loggerConstant.ERR, err.Error()))...)
+	} else {
+		l.Info("Job Finished", fields...)
+	}
+
+	rec := RunRecord{
+		ID:         newID(),
+		Job:        job.Name,
+		Trigger:    trigger,
+		StartedAt:  start,
+		FinishedAt: end,
+		Duration:   latency,
+		Status:     status,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	s.save(rec)
+
+	return status, err
+}
+
+// save records a run; a failing store is logged and does not fail the run.
+func (s *Scheduler) save(rec RunRecord) {
+	if s.history == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.history.Save(ctx, rec); err != nil {
+		logger.FromContext(s.ctx).Named(loggerName).Error("Job History Failed",
+			zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+			zap.String(loggerConstant.NAME, rec.Job),
+			zap.String(loggerConstant.ERR, err.Error()),
+		)
+	}
+}
+
+// lock takes the lock of the run of job scheduled at tick.
+func (s *Scheduler) lock(job string, tick time.Time) (Lease, bool) {
+	key := s.lockPrefix + "cron:" + job + ":" + strconv.FormatInt(tick.Unix(), 10)
+	lease, ok, err := s.locker.TryLock(s.ctx, key, s.lockTTL)
+	l := logger.FromContext(s.ctx).Named(loggerName)
+	if err != nil {
+		l.Error("Job Lock Failed",
+			zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+			zap.String(loggerConstant.NAME, job),
+			zap.String(loggerConstant.ERR, err.Error()),
+		)
+		return nil, false
+	}
+	if !ok {
+		l.Debug("Job Locked By Another Replica",
+			zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+			zap.String(loggerConstant.NAME, job),
+			zap.String(loggerConstant.STATUS, RunSkipped),
+		)
+		return nil, false
+	}
+
+	return lease, true
+}
+
+// keepLease renews lease while the run is in progress and cancels it when the lock is
+// lost. The returned func stops the renewal and releases the lease once it is at
+// least lockTTL old, so a replica firing late finds the lock still taken.
+func (s *Scheduler) keepLease(ctx context.Context, cancel context.CancelFunc, job string, lease Lease) func() {
+	acquired := time.Now()
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := lease.Refresh(ctx, s.lockTTL); err != nil {
+					logger.FromContext(ctx).Named(loggerName).Error("Job Lock Lost",
+						zap.String(loggerConstant.TYPE, loggerConstant.WORKER),
+						zap.String(loggerConstant.NAME, job),
+						zap.String(loggerConstant.ERR, err.Error()),
+					)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		release := func() {
+			_ = lease.Release(context.Background())
+		}
+		if hold := s.lockTTL - time.Since(acquired); hold > 0 {
+			time.AfterFunc(hold, release)
+			return
+		}
+		release()
+	}
+}
+
+// alignedEvery runs at the multiples of every computed by time.Truncate, so replicas
+// started at different times share the same schedule, unlike cron.Every.
+type alignedEvery time.Duration
+
+func (a alignedEvery) Next(t time.Time) time.Time {
+	every := time.Duration(a)
+	if every < time.Second {
+		every = time.Second
+	}
+
+	return t.Truncate(every).Add(every)
+}
@@ -0,0 +1,76 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ByteSize is a number of bytes read from values such as "512", "64KB" or "1.5GiB".
+// KB/MB/GB/TB and their KiB/MiB/GiB/TiB spellings are both powers of 1024.
+type ByteSize int64
+
+const (
+	Byte ByteSize = 1
+	KB            = Byte << 10
+	MB            = KB << 10
+	GB            = MB << 10
+	TB            = GB << 10
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"B":   Byte,
+	"K":   KB,
+	"KB":  KB,
+	"KIB": KB,
+	"M":   MB,
+	"MB":  MB,
+	"MIB": MB,
+	"G":   GB,
+	"GB":  GB,
+	"GIB": GB,
+	"T":   TB,
+	"TB":  TB,
+	"TIB": TB,
+}
+
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok || num == "" {
+		return 0, errors.Errorf("could not convert %q to byte size", s)
+	}
+
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, errors.Errorf("could not convert %q to byte size", s)
+	}
+
+	return ByteSize(val * float64(multiplier)), nil
+}
+
+func (b ByteSize) String() string {
+	switch {
+	case b >= TB && b%TB == 0:
+		return fmt.Sprintf("%dTB", b/TB)
+	case b >= GB && b%GB == 0:
+		return fmt.Sprintf("%dGB", b/GB)
+	case b >= MB && b%MB == 0:
+		return fmt.Sprintf("%dMB", b/MB)
+	case b >= KB && b%KB == 0:
+		return fmt.Sprintf("%dKB", b/KB)
+	default:
+		return fmt.Sprintf("%dB", int64(b))
+	}
+}
@@ -0,0 +1,56 @@
+package env
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{in: "512", want: 512},
+		{in: "512B", want: 512},
+		{in: "64KB", want: 64 * KB},
+		{in: "64kb", want: 64 * KB},
+		{in: "64 KiB", want: 64 * KB},
+		{in: "100MB", want: 100 * MB},
+		{in: "1.5GiB", want: GB + GB/2},
+		{in: "2T", want: 2 * TB},
+		{in: " 10MB ", want: 10 * MB},
+		{in: "", wantErr: true},
+		{in: "MB", wantErr: true},
+		{in: "10XB", wantErr: true},
+		{in: "1.2.3MB", wantErr: true},
+		{in: "-1MB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseByteSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseByteSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := map[ByteSize]string{
+		0:         "0B",
+		512:       "512B",
+		64 * KB:   "64KB",
+		100 * MB:  "100MB",
+		GB + GB/2: "1536MB",
+		3 * TB:    "3TB",
+		KB + 1:    "1025B",
+	}
+
+	for size, want := range tests {
+		if got := size.String(); got != want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", int64(size), got, want)
+		}
+	}
+}
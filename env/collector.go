@@ -0,0 +1,113 @@
+package env
+
+import (
+	"net/url"
+	"time"
+)
+
+// Collector reads env variables without failing fast. Every missing or invalid
+// variable is recorded and the zero value is returned, so all problems can be
+// reported at once through Err.
+type Collector struct {
+	errs Errors
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+func (c *Collector) Add(err error) {
+	if err != nil {
+		c.errs = append(c.errs, err)
+	}
+}
+
+func (c *Collector) String(eVar *EVar) string {
+	val, err := eVar.TryString()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Int(eVar *EVar) int {
+	val, err := eVar.TryInt()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Bool(eVar *EVar) bool {
+	val, err := eVar.TryBool()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) StringSlice(eVar *EVar, sep string) []string {
+	val, err := eVar.TryStringSlice(sep)
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Int64(eVar *EVar) int64 {
+	val, err := eVar.TryInt64()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Float64(eVar *EVar) float64 {
+	val, err := eVar.TryFloat64()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Duration(eVar *EVar) time.Duration {
+	val, err := eVar.TryDuration()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) URL(eVar *EVar) *url.URL {
+	val, err := eVar.TryURL()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Map(eVar *EVar, pairSep string, kvSep string) map[string]string {
+	val, err := eVar.TryMap(pairSep, kvSep)
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) BytesSize(eVar *EVar) ByteSize {
+	val, err := eVar.TryBytesSize()
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Enum(eVar *EVar, allowed ...string) string {
+	val, err := eVar.TryEnum(allowed...)
+	c.Add(err)
+
+	return val
+}
+
+func (c *Collector) Errors() Errors {
+	return c.errs
+}
+
+// Err returns nil when nothing was recorded, otherwise an Errors value.
+func (c *Collector) Err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+
+	return c.errs
+}
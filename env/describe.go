@@ -0,0 +1,66 @@
+package env
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const SourceUnset = "unset"
+
+// Field describes the effective value of an env-tagged struct field.
+type Field struct {
+	Key    string `json:"key"`
+	Path   string `json:"path"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Describe lists every env-tagged field of the struct pointed to by v with its
+// current value, secrets redacted, and the source recorded by the current layers.
+func Describe(v interface{}) ([]Field, error) {
+	return DescribeWithPrefix("", v)
+}
+
+// DescribeWithPrefix is Describe for a struct loaded with LoadWithPrefix.
+func DescribeWithPrefix(prefix string, v interface{}) ([]Field, error) {
+	var fields []Field
+	err := walkPathsWithPrefix(prefix, v, func(key string, path string, field fieldInfo) {
+		f := Field{
+			Key:    key,
+			Path:   path,
+			Value:  formatValue(field),
+			Source: CurrentLayers().Origin(key),
+			Secret: IsSecretField(field.StructField),
+		}
+		if f.Source == "" {
+			f.Source = SourceUnset
+		}
+		if f.Secret && f.Value != "" {
+			f.Value = Redacted
+		}
+		fields = append(fields, f)
+	})
+
+	return fields, err
+}
+
+func formatValue(field fieldInfo) string {
+	if field.Value.Kind() == reflect.Ptr && field.Value.IsNil() {
+		return ""
+	}
+
+	val := field.Value.Interface()
+	switch v := val.(type) {
+	case url.URL:
+		return v.String()
+	case []string:
+		return strings.Join(v, tagOr(field.StructField, TagSep, defaultSep))
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
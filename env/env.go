@@ -3,40 +3,49 @@ package env
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
-	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
 )
 
 type EVar struct {
 	key        string
 	defaultVal interface{}
+	// layers resolves the variable instead of CurrentLayers when set, see In.
+	layers *Layers
 }
 
 func init() {
 	//LoadEnv()
 }
 
+// LoadEnv loads .env files into the process environment. Without arguments it
+// loads the optional .env, .env.<APP_ENV>, .env.local and .env.<APP_ENV>.local
+// files from the working directory; explicitly given files must exist. Prefer
+// NewStandardLayers with SetLayers, which also reports where each value came from.
 func LoadEnv(customEnvPath ...string) {
-	_, callerDir, _, ok := runtime.Caller(0)
-	if !ok {
-		log.Fatal("Error generating env dir")
-	}
-
-	var dir string
 	if customEnvPath != nil {
-		dir = customEnvPath[0]
-	} else {
-		dir = filepath.Join(filepath.Dir(callerDir), "../..", "envs/.env")
+		if err := godotenv.Load(customEnvPath...); err != nil {
+			log.Fatalf("Error loading .env file: %v", err)
+		}
+		return
 	}
 
-	err := godotenv.Load(dir)
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	// godotenv never overrides a variable that is already set, so the most
+	// specific file has to be loaded first.
+	names := []string{".env.local", ".env"}
+	if appEnv := os.Getenv("APP_ENV"); appEnv != "" {
+		names = []string{".env." + appEnv + ".local", ".env.local", ".env." + appEnv, ".env"}
+	}
+	for _, name := range names {
+		if err := godotenv.Load(name); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("Error loading %s file: %v", name, err)
+		}
 	}
 }
 
@@ -44,16 +53,113 @@ func New(key string, defaultVal interface{}) *EVar {
 	return &EVar{key: key, defaultVal: defaultVal}
 }
 
-func (eVar EVar) GetEnv() interface{} {
-	if val, exists := os.LookupEnv(eVar.key); exists {
-		return val
+// In resolves the variable through l instead of the current layers, e.g. to read a
+// snapshot before SetLayers publishes it.
+func (eVar EVar) In(l *Layers) *EVar {
+	eVar.layers = l
+	return &eVar
+}
+
+func (eVar EVar) currentLayers() *Layers {
+	if eVar.layers != nil {
+		return eVar.layers
+	}
+
+	return CurrentLayers()
+}
+
+func (eVar EVar) Key() string {
+	return eVar.key
+}
+
+// Lookup returns the raw value of the variable, falling back to the default value.
+// The boolean is false when the variable is neither set nor has a default; the
+// error reports a set variable that could not be read, e.g. a missing _FILE.
+func (eVar EVar) Lookup() (string, bool, error) {
+	val, err := eVar.TryGetEnv()
+	if errors.Is(err, ErrRequired) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", true, err
+	}
+
+	return fmt.Sprintf("%v", val), true, nil
+}
+
+// TryGetEnv resolves the variable through the current Layers, which by default only
+// hold the process environment. When the variable is not set, <KEY>_FILE is read
+// instead, and secret:// values are resolved through the SecretProvider.
+func (eVar EVar) TryGetEnv() (interface{}, error) {
+	layers := eVar.currentLayers()
+	if val, exists, err := lookup(layers, eVar.key); exists {
+		if err != nil {
+			return nil, err
+		}
+		return val, nil
 	}
 
 	if eVar.defaultVal == nil {
-		log.Fatalf("Env variable is required %v", eVar.key)
+		return nil, newVarError(eVar.key, ErrRequired)
 	}
+	layers.setOrigin(eVar.key, SourceDefault)
 
-	return eVar.defaultVal
+	return eVar.defaultVal, nil
+}
+
+func (eVar EVar) TryString() (string, error) {
+	val, err := eVar.TryGetEnv()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%v", val), nil
+}
+
+func (eVar EVar) TryInt() (int, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := strconv.Atoi(valStr)
+	if err != nil {
+		return 0, newVarError(eVar.key, fmt.Errorf("%w: could not convert %q to int", ErrInvalid, valStr))
+	}
+
+	return val, nil
+}
+
+func (eVar EVar) TryBool() (bool, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return false, err
+	}
+
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		return false, newVarError(eVar.key, fmt.Errorf("%w: could not convert %q to bool", ErrInvalid, valStr))
+	}
+
+	return val, nil
+}
+
+func (eVar EVar) TryStringSlice(sep string) ([]string, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(valStr, sep), nil
+}
+
+func (eVar EVar) GetEnv() interface{} {
+	val, err := eVar.TryGetEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
 }
 
 func (eVar EVar) AsString() string {
@@ -61,18 +167,18 @@ func (eVar EVar) AsString() string {
 }
 
 func (eVar EVar) AsInt() int {
-	val, err := strconv.Atoi(eVar.AsString())
+	val, err := eVar.TryInt()
 	if err != nil {
-		log.Fatalf("could not convert eVar to Int %v", eVar.key)
+		log.Fatal(err)
 	}
 
 	return val
 }
 
 func (eVar EVar) AsBool() bool {
-	val, err := strconv.ParseBool(eVar.AsString())
+	val, err := eVar.TryBool()
 	if err != nil {
-		log.Fatalf("could not convert eVar to bool %v", eVar.key)
+		log.Fatal(err)
 	}
 
 	return val
@@ -83,3 +189,182 @@ func (eVar EVar) AsStringSlice(sep string) []string {
 
 	return strings.Split(valStr, sep)
 }
+
+func (eVar EVar) TryInt64() (int64, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := strconv.ParseInt(strings.TrimSpace(valStr), 10, 64)
+	if err != nil {
+		return 0, newVarError(eVar.key, fmt.Errorf("%w: could not convert %q to int64", ErrInvalid, valStr))
+	}
+
+	return val, nil
+}
+
+func (eVar EVar) TryFloat64() (float64, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := strconv.ParseFloat(strings.TrimSpace(valStr), 64)
+	if err != nil {
+		return 0, newVarError(eVar.key, fmt.Errorf("%w: could not convert %q to float64", ErrInvalid, valStr))
+	}
+
+	return val, nil
+}
+
+// TryDuration accepts Go duration strings such as "300ms" or "1h30m". A bare
+// integer is read as a number of seconds.
+func (eVar EVar) TryDuration() (time.Duration, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return 0, err
+	}
+
+	valStr = strings.TrimSpace(valStr)
+	if secs, err := strconv.ParseInt(valStr, 10, 64); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+
+	val, err := time.ParseDuration(valStr)
+	if err != nil {
+		return 0, newVarError(eVar.key, fmt.Errorf("%w: could not convert %q to duration", ErrInvalid, valStr))
+	}
+
+	return val, nil
+}
+
+// TryURL only accepts absolute URLs, e.g. "https://example.com" or "redis://localhost:6379/0".
+func (eVar EVar) TryURL() (*url.URL, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := url.Parse(strings.TrimSpace(valStr))
+	if err != nil || val.Scheme == "" || val.Host == "" {
+		return nil, newVarError(eVar.key, fmt.Errorf("%w: could not convert %q to absolute url", ErrInvalid, valStr))
+	}
+
+	return val, nil
+}
+
+// TryMap reads values such as "a=1,b=2" with pairSep "," and kvSep "=".
+func (eVar EVar) TryMap(pairSep string, kvSep string) (map[string]string, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return nil, err
+	}
+
+	val := make(map[string]string)
+	for _, pair := range strings.Split(valStr, pairSep) {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, kvSep)
+		if !ok {
+			return nil, newVarError(eVar.key, fmt.Errorf("%w: pair %q has no %q separator", ErrInvalid, pair, kvSep))
+		}
+		val[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return val, nil
+}
+
+func (eVar EVar) TryBytesSize() (ByteSize, error) {
+	valStr, err := eVar.TryString()
+	if err != nil {
+		return 0, err
+	}
+
+	val, err := ParseByteSize(valStr)
+	if err != nil {
+		return 0, newVarError(eVar.key, fmt.Errorf("%w: %s", ErrInvalid, err.Error()))
+	}
+
+	return val, nil
+}
+
+// TryEnum returns the value only if it is one of allowed (case-sensitive).
+func (eVar EVar) TryEnum(allowed ...string) (string, error) {
+	val, err := eVar.TryString()
+	if err != nil {
+		return "", err
+	}
+
+	for _, a := range allowed {
+		if val == a {
+			return val, nil
+		}
+	}
+
+	return "", newVarError(eVar.key, fmt.Errorf("%w: %q is not one of [%s]", ErrInvalid, val, strings.Join(allowed, ", ")))
+}
+
+func (eVar EVar) AsInt64() int64 {
+	val, err := eVar.TryInt64()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func (eVar EVar) AsFloat64() float64 {
+	val, err := eVar.TryFloat64()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func (eVar EVar) AsDuration() time.Duration {
+	val, err := eVar.TryDuration()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func (eVar EVar) AsURL() *url.URL {
+	val, err := eVar.TryURL()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func (eVar EVar) AsMap(pairSep string, kvSep string) map[string]string {
+	val, err := eVar.TryMap(pairSep, kvSep)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func (eVar EVar) AsBytesSize() ByteSize {
+	val, err := eVar.TryBytesSize()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
+
+func (eVar EVar) AsEnum(allowed ...string) string {
+	val, err := eVar.TryEnum(allowed...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return val
+}
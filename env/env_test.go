@@ -0,0 +1,103 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testVar resolves key through a layer holding only val.
+func testVar(key string, val string) *EVar {
+	return New(key, nil).In(NewLayers(NewMapSource("test", map[string]string{key: val})))
+}
+
+func TestTryDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30", want: 30 * time.Second},
+		{in: " 5 ", want: 5 * time.Second},
+		{in: "0", want: 0},
+		{in: "300ms", want: 300 * time.Millisecond},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "1.5s", want: 1500 * time.Millisecond},
+		{in: "-2s", want: -2 * time.Second},
+		{in: "", wantErr: true},
+		{in: "5 minutes", wantErr: true},
+		{in: "1.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := testVar("TIMEOUT", tt.in).TryDuration()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TryDuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("TryDuration(%q) error = %v, want ErrInvalid", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("TryDuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTryMap(t *testing.T) {
+	tests := []struct {
+		in      string
+		pairSep string
+		kvSep   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{in: "a=1,b=2", pairSep: ",", kvSep: "=", want: map[string]string{"a": "1", "b": "2"}},
+		{in: " a = 1 , b=2 ,", pairSep: ",", kvSep: "=", want: map[string]string{"a": "1", "b": "2"}},
+		{in: "a:1;b:", pairSep: ";", kvSep: ":", want: map[string]string{"a": "1", "b": ""}},
+		{in: "url=http://x?y=z", pairSep: ",", kvSep: "=", want: map[string]string{"url": "http://x?y=z"}},
+		{in: "", pairSep: ",", kvSep: "=", want: map[string]string{}},
+		{in: "a=1,b", pairSep: ",", kvSep: "=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := testVar("LABELS", tt.in).TryMap(tt.pairSep, tt.kvSep)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TryMap(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TryMap(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTryURL(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantHost string
+		wantErr  bool
+	}{
+		{in: "https://example.com/path", wantHost: "example.com"},
+		{in: " redis://localhost:6379/0 ", wantHost: "localhost:6379"},
+		{in: "example.com", wantErr: true},
+		{in: "/relative/path", wantErr: true},
+		{in: "https://", wantErr: true},
+		{in: "://bad", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := testVar("ENDPOINT", tt.in).TryURL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TryURL(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Host != tt.wantHost {
+				t.Errorf("TryURL(%q).Host = %q, want %q", tt.in, got.Host, tt.wantHost)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package env
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRequired = errors.New("env variable is required")
+	ErrInvalid  = errors.New("env variable is invalid")
+)
+
+type VarError struct {
+	Key string
+	Err error
+}
+
+func newVarError(key string, err error) *VarError {
+	return &VarError{Key: key, Err: err}
+}
+
+func (ve *VarError) Error() string {
+	return ve.Key + ": " + ve.Err.Error()
+}
+
+func (ve *VarError) Unwrap() error {
+	return ve.Err
+}
+
+// Errors aggregates every problem found while reading a set of env variables.
+type Errors []error
+
+func (es Errors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (es Errors) Unwrap() []error {
+	return es
+}
+
+// Keys returns the env keys of every VarError in the aggregate.
+func (es Errors) Keys() []string {
+	keys := make([]string, 0, len(es))
+	for _, e := range es {
+		var ve *VarError
+		if errors.As(e, &ve) {
+			keys = append(keys, ve.Key)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,220 @@
+package env
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Layers merges several sources. Sources are ordered from lowest to highest
+// precedence, so the last source that has a key wins. Every lookup records the
+// source the final value came from.
+type Layers struct {
+	mu      sync.RWMutex
+	sources []Source
+	// base sits below every source, e.g. the defaults of the active profile.
+	base Source
+
+	// origins has its own lock so lookups can record them under the read lock.
+	originsMu sync.Mutex
+	origins   map[string]string
+}
+
+// current is swapped by SetLayers, e.g. by a config reload, while requests read it.
+var current atomic.Pointer[Layers]
+
+func init() {
+	current.Store(NewLayers(NewOSSource()))
+}
+
+func NewLayers(sources ...Source) *Layers {
+	return &Layers{sources: sources, origins: map[string]string{}}
+}
+
+// SetLayers replaces the layers used by EVar and Load. By default only the process
+// environment is consulted.
+func SetLayers(l *Layers) {
+	current.Store(l)
+}
+
+func CurrentLayers() *Layers {
+	return current.Load()
+}
+
+// Add appends a source with the highest precedence so far.
+func (l *Layers) Add(s Source) *Layers {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sources = append(l.sources, s)
+
+	return l
+}
+
+// SetBase replaces the source consulted after every other source, just above the
+// default tags of a struct.
+func (l *Layers) SetBase(s Source) *Layers {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.base = s
+
+	return l
+}
+
+func (l *Layers) Sources() []Source {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return append([]Source(nil), l.sources...)
+}
+
+func (l *Layers) Lookup(key string) (string, string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for i := len(l.sources) - 1; i >= 0; i-- {
+		if val, ok := l.sources[i].Lookup(key); ok {
+			name := l.sources[i].Name()
+			l.setOrigin(key, name)
+			return val, name, true
+		}
+	}
+
+	if l.base != nil {
+		if val, ok := l.base.Lookup(key); ok {
+			l.setOrigin(key, l.base.Name())
+			return val, l.base.Name(), true
+		}
+	}
+
+	return "", "", false
+}
+
+// Keys lists every key known to the sources implementing Lister.
+func (l *Layers) Keys() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	seen := map[string]struct{}{}
+	for _, s := range append([]Source{l.base}, l.sources...) {
+		if lister, ok := s.(Lister); ok {
+			for _, k := range lister.Keys() {
+				seen[k] = struct{}{}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Origin returns the name of the source the last lookup of key was resolved from,
+// SourceDefault when the field default was used, or "" if the key was never read.
+func (l *Layers) Origin(key string) string {
+	l.originsMu.Lock()
+	defer l.originsMu.Unlock()
+
+	return l.origins[key]
+}
+
+func (l *Layers) Origins() map[string]string {
+	l.originsMu.Lock()
+	defer l.originsMu.Unlock()
+
+	origins := make(map[string]string, len(l.origins))
+	for k, v := range l.origins {
+		origins[k] = v
+	}
+
+	return origins
+}
+
+func (l *Layers) setOrigin(key string, name string) {
+	l.originsMu.Lock()
+	l.origins[key] = name
+	l.originsMu.Unlock()
+}
+
+func Origin(key string) string {
+	return CurrentLayers().Origin(key)
+}
+
+func Origins() map[string]string {
+	return CurrentLayers().Origins()
+}
+
+type LayerOptions struct {
+	// Defaults has the lowest precedence, just above the default tags of a struct.
+	Defaults map[string]string
+	// ConfigFile is an optional YAML, JSON or TOML file.
+	ConfigFile string
+	// EnvDir is the directory holding the .env files, the working directory if empty.
+	EnvDir string
+	// AppEnv selects the .env.<AppEnv> files. When empty it is resolved from APP_ENV
+	// in the process environment, the config file or the base .env file.
+	AppEnv string
+	// Flags must already be parsed; only explicitly set flags are used.
+	Flags *flag.FlagSet
+}
+
+// NewStandardLayers builds the layers below, from lowest to highest precedence:
+//
+//  1. default tags of the loaded struct, then the base source (see SetBase)
+//  2. opts.Defaults
+//  3. opts.ConfigFile
+//  4. .env, .env.<AppEnv>, .env.local, .env.<AppEnv>.local in opts.EnvDir
+//  5. process environment
+//  6. command-line flags
+func NewStandardLayers(opts LayerOptions) (*Layers, error) {
+	l := NewLayers(NewMapSource(SourceDefaults, opts.Defaults))
+
+	if opts.ConfigFile != "" {
+		fs, err := NewFileSource(opts.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		l.Add(fs)
+	}
+
+	base, err := NewDotEnvSource(filepath.Join(opts.EnvDir, ".env"))
+	if err != nil {
+		return nil, err
+	}
+	l.Add(base)
+
+	appEnv := opts.AppEnv
+	if appEnv == "" {
+		if val, ok := os.LookupEnv("APP_ENV"); ok {
+			appEnv = val
+		} else if val, _, ok := l.Lookup("APP_ENV"); ok {
+			appEnv = val
+		}
+	}
+
+	names := []string{".env.local"}
+	if appEnv != "" {
+		names = []string{".env." + appEnv, ".env.local", ".env." + appEnv + ".local"}
+	}
+	for _, name := range names {
+		s, err := NewDotEnvSource(filepath.Join(opts.EnvDir, name))
+		if err != nil {
+			return nil, err
+		}
+		l.Add(s)
+	}
+
+	l.Add(NewOSSource())
+	if opts.Flags != nil {
+		l.Add(NewFlagSource(opts.Flags))
+	}
+	l.origins = map[string]string{}
+
+	return l, nil
+}
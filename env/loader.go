@@ -0,0 +1,258 @@
+package env
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TagEnv      = "env"
+	TagDefault  = "default"
+	TagRequired = "required"
+	TagSep      = "sep"
+	TagPrefix   = "prefix"
+	TagKVSep    = "kvsep"
+	TagEnum     = "enum"
+
+	defaultSep   = ","
+	defaultKVSep = "="
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+	urlType      = reflect.TypeOf(url.URL{})
+	urlPtrType   = reflect.TypeOf(&url.URL{})
+)
+
+// Load populates the struct pointed to by v from env variables, driven by field tags:
+//
+//	Host    string   `env:"PG_HOST" required:"true"`
+//	Port    int      `env:"PG_PORT" default:"5432"`
+//	Brokers []string `env:"KAFKA_CLIENT_BROKERS" sep:","`
+//	Labels  map[string]string `env:"LABELS" sep:"," kvsep:"="`
+//	SslMode string   `env:"PG_SSL_MODE" enum:"disable,require"`
+//	Ext     ExtConf  `prefix:"EXT_PAYMENT_GRPC_"`
+//
+// Nested structs without an env tag are loaded recursively, with their prefix tag
+// appended to the current prefix. A variable that is not set and has no default
+// leaves the field untouched unless it is required. Every problem is collected and
+// returned as Errors.
+func Load(v interface{}) error {
+	return LoadWithPrefix("", v)
+}
+
+func LoadWithPrefix(prefix string, v interface{}) error {
+	return CurrentLayers().LoadWithPrefix(prefix, v)
+}
+
+// Load is env.Load resolved through l instead of the current layers.
+func (l *Layers) Load(v interface{}) error {
+	return l.LoadWithPrefix("", v)
+}
+
+func (l *Layers) LoadWithPrefix(prefix string, v interface{}) error {
+	c := NewCollector()
+	err := walkWithPrefix(prefix, v, func(key string, field fieldInfo) {
+		var defaultVal interface{}
+		if def, ok := field.Tag.Lookup(TagDefault); ok {
+			defaultVal = def
+		}
+		eVar := New(key, defaultVal).In(l)
+
+		// A _FILE that cannot be read or a secret:// that does not resolve is an
+		// error, not an unset variable.
+		_, exists, err := lookup(l, key)
+		if exists && err != nil {
+			c.Add(err)
+			return
+		}
+		if !exists && defaultVal == nil {
+			if required, _ := strconv.ParseBool(field.Tag.Get(TagRequired)); required {
+				c.Add(newVarError(eVar.key, ErrRequired))
+			}
+			return
+		}
+
+		c.Add(setField(eVar, field.StructField, field.Value))
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Err()
+}
+
+type fieldInfo struct {
+	reflect.StructField
+	Value reflect.Value
+}
+
+func walk(v interface{}, fn func(key string, field fieldInfo)) error {
+	return walkWithPrefix("", v, fn)
+}
+
+func walkWithPrefix(prefix string, v interface{}, fn func(key string, field fieldInfo)) error {
+	return walkPathsWithPrefix(prefix, v, func(key string, _ string, field fieldInfo) {
+		fn(key, field)
+	})
+}
+
+func walkPaths(v interface{}, fn func(key string, path string, field fieldInfo)) error {
+	return walkPathsWithPrefix("", v, fn)
+}
+
+func walkPathsWithPrefix(prefix string, v interface{}, fn func(key string, path string, field fieldInfo)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("env: expected a non-nil pointer to a struct")
+	}
+
+	walkStruct(prefix, "", rv.Elem(), fn)
+
+	return nil
+}
+
+// walkStruct calls fn for every env-tagged field with its full key and its dotted
+// field path, e.g. PG_HOST and Postgres.Host.
+func walkStruct(prefix string, path string, rv reflect.Value, fn func(key string, path string, field fieldInfo)) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key, hasKey := field.Tag.Lookup(TagEnv)
+		if key == "-" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		if !hasKey {
+			if fv.Kind() == reflect.Struct && fv.Type() != urlType {
+				walkStruct(prefix+field.Tag.Get(TagPrefix), fieldPath, fv, fn)
+			}
+			continue
+		}
+
+		fn(prefix+key, fieldPath, fieldInfo{StructField: field, Value: fv})
+	}
+}
+
+func setField(eVar *EVar, field reflect.StructField, fv reflect.Value) error {
+	switch fv.Type() {
+	case durationType:
+		val, err := eVar.TryDuration()
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(val))
+		return nil
+
+	case byteSizeType:
+		val, err := eVar.TryBytesSize()
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(val))
+		return nil
+
+	case urlType, urlPtrType:
+		val, err := eVar.TryURL()
+		if err != nil {
+			return err
+		}
+		if fv.Type() == urlType {
+			fv.Set(reflect.ValueOf(*val))
+		} else {
+			fv.Set(reflect.ValueOf(val))
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		var val string
+		var err error
+		if enum, ok := field.Tag.Lookup(TagEnum); ok {
+			val, err = eVar.TryEnum(strings.Split(enum, ",")...)
+		} else {
+			val, err = eVar.TryString()
+		}
+		if err != nil {
+			return err
+		}
+		fv.SetString(val)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, err := eVar.TryInt64()
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(val) {
+			return newVarError(eVar.key, fmt.Errorf("%w: %d overflows %s", ErrInvalid, val, fv.Type()))
+		}
+		fv.SetInt(val)
+
+	case reflect.Float32, reflect.Float64:
+		val, err := eVar.TryFloat64()
+		if err != nil {
+			return err
+		}
+		if fv.OverflowFloat(val) {
+			return newVarError(eVar.key, fmt.Errorf("%w: %v overflows %s", ErrInvalid, val, fv.Type()))
+		}
+		fv.SetFloat(val)
+
+	case reflect.Bool:
+		val, err := eVar.TryBool()
+		if err != nil {
+			return err
+		}
+		fv.SetBool(val)
+
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return newVarError(eVar.key, fmt.Errorf("%w: unsupported field type %s", ErrInvalid, fv.Type()))
+		}
+		val, err := eVar.TryStringSlice(tagOr(field, TagSep, defaultSep))
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(val).Convert(fv.Type()))
+
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String || fv.Type().Elem().Kind() != reflect.String {
+			return newVarError(eVar.key, fmt.Errorf("%w: unsupported field type %s", ErrInvalid, fv.Type()))
+		}
+		val, err := eVar.TryMap(tagOr(field, TagSep, defaultSep), tagOr(field, TagKVSep, defaultKVSep))
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(val).Convert(fv.Type()))
+
+	default:
+		return newVarError(eVar.key, fmt.Errorf("%w: unsupported field type %s", ErrInvalid, fv.Type()))
+	}
+
+	return nil
+}
+
+func tagOr(field reflect.StructField, tag string, fallback string) string {
+	if val, ok := field.Tag.Lookup(tag); ok {
+		return val
+	}
+
+	return fallback
+}
@@ -0,0 +1,116 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type secretConf struct {
+	User string `env:"DB_USER" default:"app"`
+	Pass string `env:"DB_PASS" required:"true"`
+}
+
+func TestLoadSecrets(t *testing.T) {
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "pass")
+	if err := os.WriteFile(passFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		vars        map[string]string
+		provider    SecretProvider
+		want        secretConf
+		wantErrKeys []string
+		wantErr     error
+	}{
+		{
+			name: "plain value",
+			vars: map[string]string{"DB_PASS": "plain"},
+			want: secretConf{User: "app", Pass: "plain"},
+		},
+		{
+			name: "file with trailing newline",
+			vars: map[string]string{"DB_PASS_FILE": passFile},
+			want: secretConf{User: "app", Pass: "from-file"},
+		},
+		{
+			name: "value wins over file",
+			vars: map[string]string{"DB_PASS": "plain", "DB_PASS_FILE": passFile},
+			want: secretConf{User: "app", Pass: "plain"},
+		},
+		{
+			name: "file for a defaulted field",
+			vars: map[string]string{"DB_USER_FILE": passFile, "DB_PASS": "plain"},
+			want: secretConf{User: "from-file", Pass: "plain"},
+		},
+		{
+			name:        "missing file",
+			vars:        map[string]string{"DB_PASS_FILE": filepath.Join(dir, "missing")},
+			wantErrKeys: []string{"DB_PASS"},
+			wantErr:     os.ErrNotExist,
+		},
+		{
+			name:     "secret reference",
+			vars:     map[string]string{"DB_PASS": "secret://db/pass"},
+			provider: NewMemorySecretProvider(map[string]string{"db/pass": "from-provider"}),
+			want:     secretConf{User: "app", Pass: "from-provider"},
+		},
+		{
+			name:        "unknown secret reference",
+			vars:        map[string]string{"DB_PASS": "secret://db/other"},
+			provider:    NewMemorySecretProvider(map[string]string{"db/pass": "from-provider"}),
+			wantErrKeys: []string{"DB_PASS"},
+			wantErr:     ErrSecretNotFound,
+		},
+		{
+			name:        "secret reference without provider",
+			vars:        map[string]string{"DB_PASS": "secret://db/pass"},
+			wantErrKeys: []string{"DB_PASS"},
+			wantErr:     ErrInvalid,
+		},
+		{
+			name:        "unset required",
+			vars:        map[string]string{},
+			wantErrKeys: []string{"DB_PASS"},
+			wantErr:     ErrRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetSecretProvider(tt.provider)
+			t.Cleanup(func() { SetSecretProvider(nil) })
+
+			var got secretConf
+			err := NewLayers(NewMapSource("test", tt.vars)).Load(&got)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Load() = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != ErrRequired && errors.Is(err, ErrRequired) {
+				t.Errorf("Load() error = %v, reported as unset", err)
+			}
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Load() error = %T, want Errors", err)
+			}
+			if keys := errs.Keys(); !reflect.DeepEqual(keys, tt.wantErrKeys) {
+				t.Errorf("Load() error keys = %v, want %v", keys, tt.wantErrKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SecretScheme marks a value as a reference resolved through the SecretProvider,
+	// e.g. PG_PASS=secret://postgres/password.
+	SecretScheme = "secret://"
+	// FileSuffix points a variable at a file holding its value, e.g.
+	// PG_PASS_FILE=/run/secrets/pg is used when PG_PASS is not set.
+	FileSuffix = "_FILE"
+
+	TagSecret = "secret"
+	Redacted  = "******"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+type SecretProvider interface {
+	// Resolve returns the secret for a reference without the secret:// scheme.
+	Resolve(ref string) (string, error)
+}
+
+var secretProvider SecretProvider
+
+func SetSecretProvider(p SecretProvider) {
+	secretProvider = p
+}
+
+// FileSecretProvider reads secret://<ref> from <Dir>/<ref>, the layout used by
+// Docker and Kubernetes mounted secrets. Trailing newlines are trimmed.
+type FileSecretProvider struct {
+	Dir string
+}
+
+func NewFileSecretProvider(dir string) *FileSecretProvider {
+	return &FileSecretProvider{Dir: dir}
+}
+
+func (fp *FileSecretProvider) Resolve(ref string) (string, error) {
+	path := filepath.Join(fp.Dir, filepath.Clean("/"+ref))
+	val, err := readSecretFile(path)
+	if os.IsNotExist(errors.Cause(err)) {
+		return "", errors.Wrap(ErrSecretNotFound, ref)
+	}
+
+	return val, err
+}
+
+type MemorySecretProvider struct {
+	mu      sync.RWMutex
+	secrets map[string]string
+}
+
+func NewMemorySecretProvider(secrets map[string]string) *MemorySecretProvider {
+	mp := &MemorySecretProvider{secrets: map[string]string{}}
+	for k, v := range secrets {
+		mp.secrets[k] = v
+	}
+
+	return mp
+}
+
+func (mp *MemorySecretProvider) Set(ref string, val string) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.secrets[ref] = val
+}
+
+func (mp *MemorySecretProvider) Resolve(ref string) (string, error) {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	val, ok := mp.secrets[ref]
+	if !ok {
+		return "", errors.Wrap(ErrSecretNotFound, ref)
+	}
+
+	return val, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read secret file %s", path)
+	}
+
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+// lookup resolves key through layers, then through <key>_FILE, and dereferences
+// secret:// values.
+func lookup(layers *Layers, key string) (string, bool, error) {
+	if val, _, ok := layers.Lookup(key); ok {
+		if !strings.HasPrefix(val, SecretScheme) {
+			return val, true, nil
+		}
+		if secretProvider == nil {
+			return "", true, newVarError(key, fmt.Errorf("%w: no secret provider configured for %q", ErrInvalid, val))
+		}
+		resolved, err := secretProvider.Resolve(strings.TrimPrefix(val, SecretScheme))
+		if err != nil {
+			return "", true, newVarError(key, err)
+		}
+		return resolved, true, nil
+	}
+
+	if path, _, ok := layers.Lookup(key + FileSuffix); ok {
+		val, err := readSecretFile(path)
+		if err != nil {
+			return "", true, newVarError(key, err)
+		}
+		layers.setOrigin(key, "file:"+path)
+		return val, true, nil
+	}
+
+	return "", false, nil
+}
+
+// Redact returns a copy of v where every non-empty string field tagged
+// `secret:"true"` is replaced by Redacted. Nested structs are redacted as well.
+func Redact[T any](v T) T {
+	rv := reflect.New(reflect.TypeOf(&v).Elem()).Elem()
+	rv.Set(reflect.ValueOf(&v).Elem())
+	redactValue(rv)
+
+	return rv.Interface().(T)
+}
+
+func redactValue(rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+			cp := reflect.New(rv.Elem().Type())
+			cp.Elem().Set(rv.Elem())
+			redactValue(cp.Elem())
+			rv.Set(cp)
+		}
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fv := rv.Field(i)
+			if IsSecretField(field) && fv.Kind() == reflect.String {
+				if fv.String() != "" {
+					fv.SetString(Redacted)
+				}
+				continue
+			}
+			redactValue(fv)
+		}
+	}
+}
+
+func IsSecretField(field reflect.StructField) bool {
+	secret, _ := strconv.ParseBool(field.Tag.Get(TagSecret))
+	return secret
+}
@@ -0,0 +1,216 @@
+package env
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SourceDefault  = "default"
+	SourceDefaults = "defaults"
+	SourceEnv      = "env"
+	SourceFlags    = "flags"
+)
+
+// Source is a single layer of configuration values keyed by env-style names (PG_HOST).
+type Source interface {
+	Name() string
+	Lookup(key string) (string, bool)
+}
+
+// Lister is implemented by sources that can enumerate their keys.
+type Lister interface {
+	Keys() []string
+}
+
+type mapSource struct {
+	name   string
+	values map[string]string
+}
+
+func NewMapSource(name string, values map[string]string) Source {
+	if values == nil {
+		values = map[string]string{}
+	}
+
+	return &mapSource{name: name, values: values}
+}
+
+func (ms *mapSource) Name() string {
+	return ms.name
+}
+
+func (ms *mapSource) Lookup(key string) (string, bool) {
+	val, ok := ms.values[key]
+	return val, ok
+}
+
+func (ms *mapSource) Keys() []string {
+	keys := make([]string, 0, len(ms.values))
+	for k := range ms.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+type osSource struct{}
+
+// NewOSSource reads the process environment.
+func NewOSSource() Source {
+	return osSource{}
+}
+
+func (osSource) Name() string {
+	return SourceEnv
+}
+
+func (osSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (osSource) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if k, _, ok := strings.Cut(kv, "="); ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// NewDotEnvSource reads a .env file. A missing file yields an empty source so
+// optional files such as .env.local can always be listed.
+func NewDotEnvSource(path string) (Source, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return NewMapSource(path, nil), nil
+		}
+		return nil, errors.Wrapf(err, "env: could not read %s", path)
+	}
+
+	return NewMapSource(path, values), nil
+}
+
+// NewFileSource reads a YAML, JSON or TOML file, chosen by extension. Nested keys are
+// flattened into env-style names, so `pg: {host: x}` is looked up as PG_HOST, and
+// lists are joined with ",".
+func NewFileSource(path string) (Source, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "env: could not read %s", path)
+	}
+
+	data := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &data)
+	case ".json":
+		err = json.Unmarshal(raw, &data)
+	case ".toml":
+		err = toml.Unmarshal(raw, &data)
+	default:
+		return nil, errors.Errorf("env: unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "env: could not parse %s", path)
+	}
+
+	values := map[string]string{}
+	flatten("", data, values)
+
+	return NewMapSource(path, values), nil
+}
+
+func flatten(prefix string, val interface{}, out map[string]string) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flatten(joinKey(prefix, k), child, out)
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprintf("%v", item))
+		}
+		out[prefix] = strings.Join(items, defaultSep)
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprintf("%v", v)
+	}
+}
+
+func joinKey(prefix string, key string) string {
+	key = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "_" + key
+}
+
+type flagSource struct {
+	values map[string]string
+}
+
+// NewFlagSource exposes the flags that were explicitly set on a parsed FlagSet.
+// Flag names map to keys by upper-casing and replacing "-" and "." with "_", so
+// --pg-host is looked up as PG_HOST.
+func NewFlagSource(fs *flag.FlagSet) Source {
+	values := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		values[joinKey("", f.Name)] = f.Value.String()
+	})
+
+	return &flagSource{values: values}
+}
+
+func (fs *flagSource) Name() string {
+	return SourceFlags
+}
+
+func (fs *flagSource) Lookup(key string) (string, bool) {
+	val, ok := fs.values[key]
+	return val, ok
+}
+
+func (fs *flagSource) Keys() []string {
+	keys := make([]string, 0, len(fs.values))
+	for k := range fs.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// FlagName is the command-line flag name used for an env key, e.g. PG_HOST -> pg-host.
+func FlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// DefineFlags registers a string flag for every env-tagged field of the struct
+// pointed to by v, so any value can be overridden from the command line.
+func DefineFlags(fs *flag.FlagSet, v interface{}) error {
+	return walk(v, func(key string, field fieldInfo) {
+		if fs.Lookup(FlagName(key)) == nil {
+			fs.String(FlagName(key), "", fmt.Sprintf("overrides %s", key))
+		}
+	})
+}
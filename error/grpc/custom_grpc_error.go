@@ -74,6 +74,17 @@ func NewGrpcForbiddenError(code int, message string, details map[string]string)
 	}
 }
 
+func NewGrpcTooManyRequestsError(code int, message string, details map[string]string) GrpcErr {
+	return &grpcErr{
+		Title:     errorConstant.ErrTooManyRequestsTitle,
+		Code:      code,
+		Msg:       message,
+		Details:   details,
+		Status:    codes.ResourceExhausted,
+		Timestamp: time.Now(),
+	}
+}
+
 func NewGrpcInternalServerError(code int, message string, details map[string]string) GrpcErr {
 	return &grpcErr{
 		Title:     errorConstant.ErrInternalServerErrorTitle,
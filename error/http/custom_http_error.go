@@ -73,6 +73,17 @@ func NewHttpForbiddenError(code int, message string, details map[string]string)
 	}
 }
 
+func NewHttpTooManyRequestsError(code int, message string, details map[string]string) HttpErr {
+	return &httpErr{
+		Title:     errorConstant.ErrTooManyRequestsTitle,
+		Code:      code,
+		Msg:       message,
+		Details:   details,
+		Status:    http.StatusTooManyRequests,
+		Timestamp: time.Now(),
+	}
+}
+
 func NewHttpInternalServerError(code int, message string, details map[string]string) HttpErr {
 	return &httpErr{
 		Title:     errorConstant.ErrInternalServerErrorTitle,
@@ -3,6 +3,7 @@ package httpError
 import (
 	"net/http"
 
+	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 
 	errorConstant "github.com/diki-haryadi/ztools/constant/error"
@@ -11,6 +12,12 @@ import (
 )
 
 func ParseError(err error) HttpErr {
+	// Middlewares may answer with a ready-made HttpErr, e.g. the 429 of the rate limiter.
+	var httpErr HttpErr
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
 	customErr := customError.AsCustomError(err)
 	if customErr == nil {
 		internalServerError := errorList.InternalErrorList.InternalServerError
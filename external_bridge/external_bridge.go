@@ -2,31 +2,164 @@ package externalBridge
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
 
 	"github.com/diki-haryadi/ztools/config"
 	"github.com/diki-haryadi/ztools/grpc"
+	httpClient "github.com/diki-haryadi/ztools/http/client"
 )
 
+var ErrServiceNotFound = errors.New("external service not found")
+
+type HttpService struct {
+	// BaseURL is scheme://host[:port] without a trailing slash.
+	BaseURL string
+	Client  *http.Client
+}
+
+// Req starts a request on the shared client of the service.
+func (hs *HttpService) Req() *httpClient.HttpRequest {
+	return httpClient.BuildReq().WithClient(hs.Client)
+}
+
+// ExternalBridge holds the clients of the services declared as
+// EXT_<NAME>_GRPC_* or EXT_<NAME>_HTTP_*, looked up by lower-cased name.
 type ExternalBridge struct {
-	SampleExtGrpcService grpc.Client /* Like ETH Service */
+	mu      sync.Mutex
+	grpc    map[string]grpc.Client
+	http    map[string]*HttpService
+	downFns []func()
+}
+
+// NewExternalBridge dials the external services of cfg, or of config.Current when cfg is nil.
+func NewExternalBridge(ctx context.Context, cfg *config.Config) (*ExternalBridge, func(), error) {
+	if cfg == nil {
+		cfg = config.Current()
+	}
+
+	eb := &ExternalBridge{
+		grpc: map[string]grpc.Client{},
+		http: map[string]*HttpService{},
+	}
+	for _, svc := range cfg.ExternalServices {
+		if err := eb.register(ctx, svc); err != nil {
+			eb.Close()
+			return nil, func() {}, err
+		}
+	}
+
+	return eb, eb.Close, nil
 }
 
-func NewExternalBridge(ctx context.Context) (*ExternalBridge, func(), error) {
-	var downFns []func()
-	down := func() {
-		for _, df := range downFns {
-			df()
+func (eb *ExternalBridge) register(ctx context.Context, svc config.ExternalServiceConfig) error {
+	retry := svc.Retry
+
+	switch svc.Protocol {
+	case config.ExtProtocolGrpc:
+		client, err := grpc.NewGrpcClientWithConfig(ctx, &grpc.ClientConfig{
+			Host:    svc.Host,
+			Port:    svc.Port,
+			TLS:     svc.TLS,
+			Timeout: svc.Timeout,
+			Retry: grpc.ClientRetry{
+				MaxAttempts:    retry.MaxAttempts,
+				InitialBackoff: retry.InitialBackoff,
+				MaxBackoff:     retry.MaxBackoff,
+			},
+		})
+		if err != nil {
+			return errors.Wrapf(err, "could not dial external service %s", svc.Name)
 		}
+		eb.grpc[svc.Name] = client
+		eb.downFns = append(eb.downFns, func() {
+			_ = client.Close()
+		})
+	case config.ExtProtocolHttp:
+		client := httpClient.NewHttpClient(&httpClient.Config{
+			Timeout: svc.Timeout,
+			Retry: httpClient.RetryConfig{
+				MaxAttempts:    retry.MaxAttempts,
+				InitialBackoff: retry.InitialBackoff,
+				MaxBackoff:     retry.MaxBackoff,
+				NonIdempotent:  retry.NonIdempotent,
+			},
+		})
+		eb.http[svc.Name] = &HttpService{BaseURL: baseURL(svc), Client: client}
+		eb.downFns = append(eb.downFns, client.CloseIdleConnections)
+	default:
+		return errors.Errorf("external service %s has unknown protocol %q", svc.Name, svc.Protocol)
+	}
+
+	return nil
+}
+
+func (eb *ExternalBridge) Grpc(name string) (grpc.Client, error) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	client, ok := eb.grpc[name]
+	if !ok {
+		return nil, errors.Wrap(ErrServiceNotFound, name)
 	}
-	sampleExtGrpcClient, _ := grpc.NewGrpcClient(
-		ctx,
-		&grpc.Config{Port: config.BaseConfig.SampleExtService.Port, Host: config.BaseConfig.SampleExtService.Host},
-	)
-	downFns = append(downFns, func() {
-		_ = sampleExtGrpcClient.Close()
-	})
 
-	cc := &ExternalBridge{SampleExtGrpcService: sampleExtGrpcClient}
+	return client, nil
+}
+
+func (eb *ExternalBridge) Http(name string) (*HttpService, error) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	svc, ok := eb.http[name]
+	if !ok {
+		return nil, errors.Wrap(ErrServiceNotFound, name)
+	}
+
+	return svc, nil
+}
+
+func (eb *ExternalBridge) Names() []string {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	names := make([]string, 0, len(eb.grpc)+len(eb.http))
+	for name := range eb.grpc {
+		names = append(names, name)
+	}
+	for name := range eb.http {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Close releases every client in reverse registration order. It is safe to call twice.
+func (eb *ExternalBridge) Close() {
+	eb.mu.Lock()
+	downFns := eb.downFns
+	eb.downFns = nil
+	eb.grpc = map[string]grpc.Client{}
+	eb.http = map[string]*HttpService{}
+	eb.mu.Unlock()
+
+	for i := len(downFns) - 1; i >= 0; i-- {
+		downFns[i]()
+	}
+}
+
+func baseURL(svc config.ExternalServiceConfig) string {
+	scheme := "http"
+	if svc.TLS {
+		scheme = "https"
+	}
+	if svc.Port == 0 {
+		return fmt.Sprintf("%s://%s", scheme, svc.Host)
+	}
 
-	return cc, down, nil
+	return fmt.Sprintf("%s://%s:%d", scheme, svc.Host, svc.Port)
 }
@@ -0,0 +1,92 @@
+package grpcAdmin
+
+import (
+	"context"
+
+	googleGrpc "google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	errorList "github.com/diki-haryadi/ztools/constant/error/error_list"
+	customError "github.com/diki-haryadi/ztools/error/custom_error"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// LogLevelServer reads and changes the log levels of the running service. Requests and
+// replies are google.protobuf.Struct so no generated code is needed:
+//
+//	GetLevel({})                                 -> {"level": "info", "named": {"kafka": "debug"}}
+//	SetLevel({"level": "warn"})                  sets the base level
+//	SetLevel({"name": "kafka", "level": "debug"}) overrides a named logger
+//	SetLevel({"name": "kafka"})                  removes the override
+type LogLevelServer interface {
+	GetLevel(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	SetLevel(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+const LogLevelServiceName = "ztools.admin.v1.LogLevelService"
+
+var LogLevelServiceDesc = googleGrpc.ServiceDesc{
+	ServiceName: LogLevelServiceName,
+	HandlerType: (*LogLevelServer)(nil),
+	Methods: []googleGrpc.MethodDesc{
+		{MethodName: "GetLevel", Handler: logLevelHandler("GetLevel", LogLevelServer.GetLevel)},
+		{MethodName: "SetLevel", Handler: logLevelHandler("SetLevel", LogLevelServer.SetLevel)},
+	},
+	Streams:  []googleGrpc.StreamDesc{},
+	Metadata: "ztools/admin/v1/log_level.proto",
+}
+
+func RegisterLogLevelServer(s *googleGrpc.Server, levels *logger.Levels) {
+	s.RegisterService(&LogLevelServiceDesc, &logLevelServer{levels: levels})
+}
+
+type logLevelServer struct {
+	levels *logger.Levels
+}
+
+func (ls *logLevelServer) GetLevel(_ context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	return stateToStruct(ls.levels.State())
+}
+
+func (ls *logLevelServer) SetLevel(_ context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	name := req.GetFields()["name"].GetStringValue()
+	level := req.GetFields()["level"].GetStringValue()
+	if err := ls.levels.Update(name, level); err != nil {
+		return nil, customError.NewBadRequestErrorWrap(err, "invalid log level", errorList.InternalErrorList.ValidationError.Code, nil)
+	}
+
+	return stateToStruct(ls.levels.State())
+}
+
+func stateToStruct(state logger.LevelState) (*structpb.Struct, error) {
+	named := make(map[string]interface{}, len(state.Named))
+	for k, v := range state.Named {
+		named[k] = v
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"level": state.Level,
+		"named": named,
+	})
+}
+
+func logLevelHandler(method string, call func(LogLevelServer, context.Context, *structpb.Struct) (*structpb.Struct, error)) func(interface{}, context.Context, func(interface{}) error, googleGrpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor googleGrpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(structpb.Struct)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(LogLevelServer), ctx, in)
+		}
+
+		info := &googleGrpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + LogLevelServiceName + "/" + method,
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(LogLevelServer), ctx, req.(*structpb.Struct))
+		}
+		return interceptor(ctx, in, info, handler)
+	}
+}
@@ -2,9 +2,12 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -17,10 +20,42 @@ type Client interface {
 	Close() error
 }
 
+type ClientConfig struct {
+	Host string
+	Port int
+	TLS  bool
+	// Timeout bounds every unary call that has no earlier deadline; zero disables it.
+	Timeout time.Duration
+	Retry   ClientRetry
+}
+
+// ClientRetry is applied as the gRPC retry policy of every method. MaxAttempts
+// includes the first call, so values below 2 disable retries.
+type ClientRetry struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
 func NewGrpcClient(ctx context.Context, config *Config) (Client, error) {
-	conn, err := grpc.DialContext(ctx, fmt.Sprintf("%s:%d", config.Host, config.Port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	return NewGrpcClientWithConfig(ctx, &ClientConfig{Host: config.Host, Port: config.Port})
+}
+
+func NewGrpcClientWithConfig(ctx context.Context, config *ClientConfig) (Client, error) {
+	creds := insecure.NewCredentials()
+	if config.TLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if config.Timeout > 0 {
+		opts = append(opts, grpc.WithUnaryInterceptor(timeoutInterceptor(config.Timeout)))
+	}
+	if sc := config.Retry.serviceConfig(); sc != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(sc))
+	}
+
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("%s:%d", config.Host, config.Port), opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -35,3 +70,31 @@ func (gc *grpcClient) GetGrpcConnection() *grpc.ClientConn {
 func (gc *grpcClient) Close() error {
 	return gc.conn.Close()
 }
+
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (r ClientRetry) serviceConfig() string {
+	if r.MaxAttempts < 2 {
+		return ""
+	}
+
+	initial, max := r.InitialBackoff, r.MaxBackoff
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if max < initial {
+		max = initial
+	}
+
+	return fmt.Sprintf(`{"methodConfig":[{"name":[{}],"retryPolicy":{"MaxAttempts":%d,"InitialBackoff":"%.3fs","MaxBackoff":"%.3fs","BackoffMultiplier":2,"RetryableStatusCodes":["UNAVAILABLE"]}}]}`,
+		r.MaxAttempts, initial.Seconds(), max.Seconds())
+}
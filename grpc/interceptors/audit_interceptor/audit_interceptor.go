@@ -0,0 +1,105 @@
+package grpcAuditInterceptor
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/diki-haryadi/ztools/audit"
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+const requestIdHeader = "x-request-id"
+
+// UnaryServerInterceptor records an audit event for every call of a method in rules.
+// It must run outside the error interceptor to see the final status code.
+func UnaryServerInterceptor(auditor *audit.Auditor, rules audit.Rules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		rule, ok := rules[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+
+		ev := newEvent(ctx, rule, info.FullMethod, err)
+		if msg, ok := req.(proto.Message); ok && rule.ResourceParam != "" {
+			ev.ResourceID = fieldValue(msg, rule.ResourceParam)
+		}
+		record(ctx, auditor, ev)
+
+		return resp, err
+	}
+}
+
+func StreamServerInterceptor(auditor *audit.Auditor, rules audit.Rules) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		rule, ok := rules[info.FullMethod]
+		if !ok {
+			return handler(srv, ss)
+		}
+
+		err := handler(srv, ss)
+		record(ss.Context(), auditor, newEvent(ss.Context(), rule, info.FullMethod, err))
+
+		return err
+	}
+}
+
+func newEvent(ctx context.Context, rule audit.Rule, method string, err error) audit.AuditEvent {
+	code := status.Code(err)
+	ev := audit.AuditEvent{
+		Action:   rule.Action,
+		Resource: rule.Resource,
+		Outcome:  audit.OutcomeSuccess,
+		Source:   audit.SourceGrpc,
+		Metadata: map[string]string{
+			"method": method,
+			"code":   code.String(),
+		},
+	}
+	switch code {
+	case codes.OK:
+	case codes.Unauthenticated, codes.PermissionDenied:
+		ev.Outcome = audit.OutcomeDenied
+		ev.Reason = code.String()
+	default:
+		ev.Outcome = audit.OutcomeFailure
+		ev.Reason = code.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIdHeader); len(ids) > 0 {
+			ev.RequestID = ids[0]
+		}
+	}
+
+	return ev
+}
+
+func record(ctx context.Context, auditor *audit.Auditor, ev audit.AuditEvent) {
+	if err := auditor.Record(ctx, ev); err != nil {
+		logger.FromContext(ctx).Error("audit record failed", zap.String(loggerConstant.NAME, ev.Action), zap.Error(err))
+	}
+}
+
+// fieldValue returns a scalar field of msg by its proto or JSON name.
+func fieldValue(msg proto.Message, name string) string {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = fields.ByJSONName(name)
+	}
+	if fd == nil || fd.IsList() || fd.IsMap() || fd.Kind() == protoreflect.MessageKind {
+		return ""
+	}
+
+	return m.Get(fd).String()
+}
@@ -48,7 +48,7 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 				hub.CaptureException(err)
 			}
 
-			logger.Zap.Error(
+			logger.FromContext(ctx).Error(
 				err.Error(),
 				zap.String(loggerConstant.TYPE, loggerConstant.GRPC),
 				zap.String(loggerConstant.TITILE, grpcErr.GetTitle()),
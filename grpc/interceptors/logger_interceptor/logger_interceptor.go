@@ -4,22 +4,29 @@ import (
 	"context"
 	"time"
 
+	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
 	"github.com/diki-haryadi/ztools/logger"
+	"github.com/diki-haryadi/ztools/redact"
 )
 
-// UnaryServerInterceptor returns a problem-detail error to client
+const requestIdHeader = "x-request-id"
+
+// UnaryServerInterceptor stores a logger carrying the request ID, method and trace
+// IDs in the context and logs the incoming request with it.
 func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		startTime := time.Now()
+		ctx = contextWithLogger(ctx, info.FullMethod)
 
-		logger.Zap.Info(
+		logger.FromContext(ctx).Info(
 			"Incoming Request",
 			zap.String(loggerConstant.TYPE, loggerConstant.GRPC),
-			zap.Any(loggerConstant.REQUEST, req),
+			redact.Any(loggerConstant.REQUEST, req),
 			zap.Time(loggerConstant.TIME, startTime),
 		)
 
@@ -29,6 +36,33 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := grpcMiddleware.WrapServerStream(ss)
+		stream.WrappedContext = contextWithLogger(ss.Context(), info.FullMethod)
+
+		logger.FromContext(stream.WrappedContext).Info(
+			"Incoming Stream",
+			zap.String(loggerConstant.TYPE, loggerConstant.GRPC),
+			zap.Time(loggerConstant.TIME, time.Now()),
+		)
+
+		return handler(srv, stream)
+	}
+}
+
+func contextWithLogger(ctx context.Context, method string) context.Context {
+	fields := []zap.Field{zap.String(loggerConstant.GRPC_METHOD, method)}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIdHeader); len(ids) > 0 {
+			fields = append(fields, zap.String(loggerConstant.REQUEST_ID, ids[0]))
+		}
+	}
+	fields = append(fields, logger.TraceFields(ctx)...)
+
+	return logger.WithContext(ctx, fields...)
+}
+
 // // StreamServerInterceptor returns a problem-detail error to client.
 // func StreamLoggerInterceptor() grpc.StreamServerInterceptor {
 // 	return func(
@@ -0,0 +1,149 @@
+package grpcRateLimitInterceptor
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	errorList "github.com/diki-haryadi/ztools/constant/error/error_list"
+	grpcError "github.com/diki-haryadi/ztools/error/grpc"
+	"github.com/diki-haryadi/ztools/ratelimit"
+)
+
+const forwardedForHeader = "x-forwarded-for"
+
+// KeyFunc selects the bucket of a call; calls with an empty key are not limited.
+type KeyFunc func(ctx context.Context) string
+
+// ByIP limits every peer address. Behind a proxy use ByForwardedIP, x-forwarded-for
+// is ignored here since any client can send it.
+func ByIP(ctx context.Context) string {
+	if host := peerHost(ctx); host != "" {
+		return "ip:" + host
+	}
+
+	return ""
+}
+
+// ByForwardedIP limits every client IP, read from x-forwarded-for when the peer is one
+// of the trusted proxies, see ratelimit.ClientIP.
+func ByForwardedIP(trusted []*net.IPNet) KeyFunc {
+	return func(ctx context.Context) string {
+		host := peerHost(ctx)
+		if host == "" {
+			return ""
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		return "ip:" + ratelimit.ClientIP(host, md.Get(forwardedForHeader), trusted)
+	}
+}
+
+// ByAPIKey limits every API key sent in the header metadata that valid accepts. Calls
+// without a valid key are left to the other keys, e.g. ByIP.
+func ByAPIKey(header string, valid ratelimit.KeyValidator) KeyFunc {
+	header = strings.ToLower(header)
+	return func(ctx context.Context) string {
+		if valid == nil {
+			return ""
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		apiKeys := md.Get(header)
+		if len(apiKeys) == 0 || apiKeys[0] == "" || !valid(ctx, apiKeys[0]) {
+			return ""
+		}
+
+		return "key:" + ratelimit.HashKey(apiKeys[0])
+	}
+}
+
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+// UnaryServerInterceptor counts every call against limit once per key and rejects it
+// with ResourceExhausted and a retry-after header when any of them is over. It must
+// run outside the error interceptor, which would turn the rejection into an internal
+// error.
+func UnaryServerInterceptor(limiter *ratelimit.Limiter, limit ratelimit.Limit, keys ...KeyFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, err := allow(ctx, limiter, limit, keys)
+		if md != nil {
+			_ = grpc.SetHeader(ctx, md)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(limiter *ratelimit.Limiter, limit ratelimit.Limit, keys ...KeyFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, err := allow(ss.Context(), limiter, limit, keys)
+		if md != nil {
+			_ = ss.SetHeader(md)
+		}
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// allow returns the rate limit headers of the call, those of its tightest key, and the
+// error rejecting it.
+func allow(ctx context.Context, limiter *ratelimit.Limiter, limit ratelimit.Limit, keys []KeyFunc) (metadata.MD, error) {
+	var res ratelimit.Result
+	limited := false
+	for _, key := range keys {
+		k := key(ctx)
+		if k == "" {
+			continue
+		}
+		r, err := limiter.Allow(ctx, k, limit)
+		if err != nil {
+			return nil, grpcError.ParseError(err).ToGrpcResponseErr()
+		}
+		if !limited || !r.Allowed || r.Remaining < res.Remaining {
+			res, limited = r, true
+		}
+		if !r.Allowed {
+			break
+		}
+	}
+	if !limited {
+		return nil, nil
+	}
+
+	md := metadata.Pairs(
+		strings.ToLower(ratelimit.HeaderLimit), strconv.Itoa(res.Limit),
+		strings.ToLower(ratelimit.HeaderRemaining), strconv.Itoa(res.Remaining),
+	)
+	if res.Allowed {
+		return md, nil
+	}
+
+	retryAfter := strconv.Itoa(res.RetryAfterSeconds())
+	md.Set(strings.ToLower(ratelimit.HeaderRetryAfter), retryAfter)
+	tooManyRequests := errorList.InternalErrorList.TooManyRequests
+	grpcErr := grpcError.NewGrpcTooManyRequestsError(tooManyRequests.Code, tooManyRequests.Msg, map[string]string{"retryAfter": retryAfter})
+
+	return md, grpcErr.ToGrpcResponseErr()
+}
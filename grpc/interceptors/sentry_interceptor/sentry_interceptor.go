@@ -7,7 +7,7 @@ import (
 	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"google.golang.org/grpc"
 
-	"github.com/diki-haryadi/ztools/config"
+	"github.com/diki-haryadi/ztools/redact"
 	sentryUtils "github.com/diki-haryadi/ztools/sentry/sentry_utils"
 )
 
@@ -21,14 +21,14 @@ func UnaryServerInterceptor(opts *sentryUtils.Options) grpc.UnaryServerIntercept
 			hub = sentry.CurrentHub().Clone()
 			ctx = sentry.SetHubOnContext(ctx, hub)
 		}
-		hub.Scope().SetExtra("request", req)
+		hub.Scope().SetExtra("request", redact.Value(req))
 		transaction := sentry.StartTransaction(ctx, info.FullMethod)
 		defer transaction.Finish()
+		ctx = transaction.Context()
 		hub.Scope().SetContext("transaction", map[string]interface{}{
 			"name": info.FullMethod,
 		})
-		hub.Scope().SetTag("application", config.BaseConfig.App.AppName)
-		hub.Scope().SetTag("AppEnv", config.BaseConfig.App.AppEnv)
+		sentryUtils.SetTags(hub, opts)
 
 		defer sentryUtils.RecoverWithSentry(hub, ctx, opts)
 
@@ -46,7 +46,6 @@ func StreamServerInterceptor(opts *sentryUtils.Options) grpc.StreamServerInterce
 		ctx := ss.Context()
 
 		stream := grpcMiddleware.WrapServerStream(ss)
-		stream.WrappedContext = ctx
 
 		hub := sentry.GetHubFromContext(ctx)
 		if hub == nil {
@@ -55,11 +54,12 @@ func StreamServerInterceptor(opts *sentryUtils.Options) grpc.StreamServerInterce
 		}
 		transaction := sentry.StartTransaction(ctx, info.FullMethod)
 		defer transaction.Finish()
+		ctx = transaction.Context()
+		stream.WrappedContext = ctx
 		hub.Scope().SetContext("transaction", map[string]interface{}{
 			"name": info.FullMethod,
 		})
-		hub.Scope().SetTag("application", config.BaseConfig.App.AppName)
-		hub.Scope().SetTag("AppEnv", config.BaseConfig.App.AppEnv)
+		sentryUtils.SetTags(hub, opts)
 
 		defer sentryUtils.RecoverWithSentry(hub, ctx, opts)
 
@@ -11,10 +11,15 @@ import (
 	googleGrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/diki-haryadi/ztools/audit"
+	grpcAdmin "github.com/diki-haryadi/ztools/grpc/admin"
+	grpcAuditInterceptor "github.com/diki-haryadi/ztools/grpc/interceptors/audit_interceptor"
 	grpcErrorInterceptor "github.com/diki-haryadi/ztools/grpc/interceptors/error_interceptor"
 	grpcLoggerInterceptor "github.com/diki-haryadi/ztools/grpc/interceptors/logger_interceptor"
+	grpcRateLimitInterceptor "github.com/diki-haryadi/ztools/grpc/interceptors/ratelimit_interceptor"
 	grpcSentryInterceptor "github.com/diki-haryadi/ztools/grpc/interceptors/sentry_interceptor"
 	"github.com/diki-haryadi/ztools/logger"
+	"github.com/diki-haryadi/ztools/ratelimit"
 	sentryUtils "github.com/diki-haryadi/ztools/sentry/sentry_utils"
 )
 
@@ -25,9 +30,26 @@ type Server interface {
 }
 
 type Config struct {
-	Port        int
-	Host        string
+	Port int
+	Host string
+	// Development registers the gRPC reflection service.
 	Development bool
+	AppName     string
+	AppEnv      string
+	// LogLevelAdmin registers the log level admin service on logger.CurrentLevels.
+	LogLevelAdmin bool
+	// Auditor records an audit event for the methods in AuditRules; nil disables it.
+	Auditor    *audit.Auditor
+	AuditRules audit.Rules
+	// RateLimiter limits every call to RateLimit per client IP and, when
+	// RateLimitKeyValidator accepts it, per API key of the RateLimitHeader metadata;
+	// nil disables it.
+	RateLimiter           *ratelimit.Limiter
+	RateLimit             ratelimit.Limit
+	RateLimitHeader       string
+	RateLimitKeyValidator ratelimit.KeyValidator
+	// TrustedProxies are the only peers whose x-forwarded-for sets the client IP.
+	TrustedProxies []*net.IPNet
 }
 
 type grpcServer struct {
@@ -38,22 +60,48 @@ type grpcServer struct {
 func NewGrpcServer(conf *Config) Server {
 	gso := &sentryUtils.Options{
 		Repanic: true,
+		Tags:    sentryUtils.AppTags(conf.AppName, conf.AppEnv),
 	}
 
+	unary := []googleGrpc.UnaryServerInterceptor{
+		grpcSentryInterceptor.UnaryServerInterceptor(gso),
+		grpcLoggerInterceptor.UnaryServerInterceptor(),
+	}
+	stream := []googleGrpc.StreamServerInterceptor{
+		grpcSentryInterceptor.StreamServerInterceptor(gso),
+		grpcLoggerInterceptor.StreamServerInterceptor(),
+	}
+	if conf.RateLimiter != nil {
+		keys := []grpcRateLimitInterceptor.KeyFunc{grpcRateLimitInterceptor.ByIP}
+		if len(conf.TrustedProxies) > 0 {
+			keys[0] = grpcRateLimitInterceptor.ByForwardedIP(conf.TrustedProxies)
+		}
+		if conf.RateLimitHeader != "" && conf.RateLimitKeyValidator != nil {
+			keys = append(keys, grpcRateLimitInterceptor.ByAPIKey(conf.RateLimitHeader, conf.RateLimitKeyValidator))
+		}
+		unary = append(unary, grpcRateLimitInterceptor.UnaryServerInterceptor(conf.RateLimiter, conf.RateLimit, keys...))
+		stream = append(stream, grpcRateLimitInterceptor.StreamServerInterceptor(conf.RateLimiter, conf.RateLimit, keys...))
+	}
+	if conf.Auditor != nil && len(conf.AuditRules) > 0 {
+		unary = append(unary, grpcAuditInterceptor.UnaryServerInterceptor(conf.Auditor, conf.AuditRules))
+		stream = append(stream, grpcAuditInterceptor.StreamServerInterceptor(conf.Auditor, conf.AuditRules))
+	}
+	unary = append(unary,
+		grpcErrorInterceptor.UnaryServerInterceptor(),
+		grpcCtxTags.UnaryServerInterceptor(),
+		grpcRecovery.UnaryServerInterceptor(),
+	)
+	stream = append(stream, grpcErrorInterceptor.StreamServerInterceptor())
+
 	s := googleGrpc.NewServer(
-		googleGrpc.UnaryInterceptor(grpcMiddleware.ChainUnaryServer(
-			grpcSentryInterceptor.UnaryServerInterceptor(gso),
-			grpcErrorInterceptor.UnaryServerInterceptor(),
-			grpcLoggerInterceptor.UnaryServerInterceptor(),
-			grpcCtxTags.UnaryServerInterceptor(),
-			grpcRecovery.UnaryServerInterceptor(),
-		)),
-		googleGrpc.StreamInterceptor(grpcMiddleware.ChainStreamServer(
-			grpcSentryInterceptor.StreamServerInterceptor(gso),
-			grpcErrorInterceptor.StreamServerInterceptor(),
-		)),
+		googleGrpc.UnaryInterceptor(grpcMiddleware.ChainUnaryServer(unary...)),
+		googleGrpc.StreamInterceptor(grpcMiddleware.ChainStreamServer(stream...)),
 	)
 
+	if conf.LogLevelAdmin {
+		grpcAdmin.RegisterLogLevelServer(s, logger.CurrentLevels())
+	}
+
 	return &grpcServer{server: s, config: conf}
 }
 
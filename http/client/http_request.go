@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 const (
@@ -17,11 +18,21 @@ const (
 	OptionsMethod = "OPTIONS"
 )
 
-type Config struct{}
+type Config struct {
+	// Timeout bounds the whole exchange, zero means no timeout.
+	Timeout time.Duration
+	Retry   RetryConfig
+}
 
 func NewHttpClient(config *Config) *http.Client {
+	transport := http.DefaultTransport
+	if config.Retry.MaxAttempts > 1 {
+		transport = &retryTransport{next: transport, retry: config.Retry}
+	}
+
 	return &http.Client{
-		Transport: http.DefaultTransport,
+		Transport: transport,
+		Timeout:   config.Timeout,
 	}
 }
 
@@ -126,6 +137,13 @@ func (request *HttpRequest) SetClient(config *Config) *HttpRequest {
 	return request
 }
 
+// WithClient reuses an existing client, e.g. one shared by an external service.
+func (request *HttpRequest) WithClient(client *http.Client) *HttpRequest {
+	request.client = client
+
+	return request
+}
+
 func (request *HttpRequest) SetContext(context context.Context) *HttpRequest {
 	request.context = context
 
@@ -0,0 +1,114 @@
+package httpClient
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 100 * time.Millisecond
+	minRetryBackoff     = 10 * time.Millisecond
+)
+
+type RetryConfig struct {
+	// MaxAttempts includes the first request, so values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff defaults to 100ms and is at least 10ms.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// NonIdempotent also retries POST and PATCH requests without an Idempotency-Key
+	// header. Only enable it when the server deduplicates them.
+	NonIdempotent bool
+}
+
+// retryTransport retries requests failing with a transport error or a 502, 503 or 504
+// response. Only idempotent requests are retried unless NonIdempotent is set, and
+// requests whose body cannot be replayed are sent once. Every retry sends a clone of
+// the request, the caller's request is never modified.
+type retryTransport struct {
+	next  http.RoundTripper
+	retry RetryConfig
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !rt.retry.NonIdempotent && !idempotent(req) {
+		return rt.next.RoundTrip(req)
+	}
+
+	backoff := rt.retry.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	backoff = max(backoff, minRetryBackoff)
+
+	attemptReq := req
+	for attempt := 1; ; attempt++ {
+		res, err := rt.next.RoundTrip(attemptReq)
+		if attempt >= rt.retry.MaxAttempts || !retryable(res, err) {
+			return res, err
+		}
+		next, ok := replay(req)
+		if !ok {
+			return res, err
+		}
+		if res != nil {
+			_ = res.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			if next.Body != nil {
+				_ = next.Body.Close()
+			}
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		attemptReq = next
+		backoff *= 2
+		if rt.retry.MaxBackoff > 0 && backoff > rt.retry.MaxBackoff {
+			backoff = max(rt.retry.MaxBackoff, minRetryBackoff)
+		}
+	}
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// replay clones req with a fresh body for the next attempt.
+func replay(req *http.Request) (*http.Request, bool) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next.Body = body
+
+	return next, true
+}
@@ -0,0 +1,93 @@
+package echoHttp
+
+import (
+	"net/http"
+	"strconv"
+
+	sentryEcho "github.com/getsentry/sentry-go/echo"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/diki-haryadi/ztools/audit"
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	httpError "github.com/diki-haryadi/ztools/error/http"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// AuditRoutes records an audit event for every request matching rules, keyed by
+// "METHOD /route/:param", e.g. "DELETE /api/v1/users/:id".
+func AuditRoutes(auditor *audit.Auditor, rules audit.Rules) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			rule, ok := rules[c.Request().Method+" "+c.Path()]
+			if !ok {
+				return next(c)
+			}
+			return auditRequest(auditor, rule, next, c)
+		}
+	}
+}
+
+// Audit records an audit event for the route it is attached to:
+//
+//	e.DELETE("/users/:id", h, echoHttp.Audit(auditor, audit.Rule{Action: "user.delete", Resource: "user", ResourceParam: "id"}))
+func Audit(auditor *audit.Auditor, rule audit.Rule) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return auditRequest(auditor, rule, next, c)
+		}
+	}
+}
+
+func auditRequest(auditor *audit.Auditor, rule audit.Rule, next echo.HandlerFunc, c echo.Context) error {
+	err := next(c)
+
+	status := c.Response().Status
+	if err != nil {
+		if he, ok := err.(*echo.HTTPError); ok {
+			status = he.Code
+		} else {
+			status = httpError.ParseError(err).GetStatus()
+		}
+	}
+
+	ev := audit.AuditEvent{
+		Action:    rule.Action,
+		Resource:  rule.Resource,
+		Outcome:   httpOutcome(status),
+		Source:    audit.SourceHttp,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		Metadata: map[string]string{
+			"method": c.Request().Method,
+			"route":  c.Path(),
+			"status": strconv.Itoa(status),
+		},
+	}
+	if rule.ResourceParam != "" {
+		ev.ResourceID = c.Param(rule.ResourceParam)
+	}
+	if ev.Outcome != audit.OutcomeSuccess {
+		ev.Reason = http.StatusText(status)
+	}
+	if span := sentryEcho.GetSpanFromContext(c); span != nil {
+		ev.TraceID = span.TraceID.String()
+	}
+
+	ctx := c.Request().Context()
+	if aerr := auditor.Record(ctx, ev); aerr != nil {
+		logger.FromContext(ctx).Error("audit record failed", zap.String(loggerConstant.NAME, rule.Action), zap.Error(aerr))
+	}
+
+	return err
+}
+
+func httpOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return audit.OutcomeDenied
+	case status >= http.StatusBadRequest:
+		return audit.OutcomeFailure
+	default:
+		return audit.OutcomeSuccess
+	}
+}
@@ -0,0 +1,102 @@
+package echoHttp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	errorList "github.com/diki-haryadi/ztools/constant/error/error_list"
+	cronJob "github.com/diki-haryadi/ztools/cron"
+	customError "github.com/diki-haryadi/ztools/error/custom_error"
+)
+
+// RegisterCronRoutes serves the jobs of scheduler under path:
+//
+//	GET  path                 jobs with their schedule and state
+//	GET  path/runs            latest runs of every job, ?limit=N
+//	GET  path/:name/runs      latest runs of a job, ?limit=N
+//	POST path/:name/trigger   run a job now
+//	POST path/:name/pause     stop its scheduled runs
+//	POST path/:name/resume    restart its scheduled runs
+//
+// A pause holds for the replicas sharing the PauseStore of the scheduler, see
+// pause_scope in the replies; without one only the replica that served the call.
+//
+// The routes are unauthenticated unless m holds an auth middleware.
+func (s *Server) RegisterCronRoutes(path string, scheduler *cronJob.Scheduler, m ...echo.MiddlewareFunc) {
+	g := s.echo.Group(path, m...)
+
+	g.GET("", func(c echo.Context) error {
+		jobs, err := scheduler.Jobs(c.Request().Context())
+		if err != nil {
+			return cronError(err)
+		}
+		return c.JSON(http.StatusOK, jobs)
+	})
+
+	g.GET("/runs", func(c echo.Context) error {
+		return cronRuns(c, scheduler, "")
+	})
+
+	g.GET("/:name/runs", func(c echo.Context) error {
+		return cronRuns(c, scheduler, c.Param("name"))
+	})
+
+	g.POST("/:name/trigger", func(c echo.Context) error {
+		if err := scheduler.Trigger(c.Param("name")); err != nil {
+			return cronError(err)
+		}
+		return cronJobInfo(c, scheduler, http.StatusAccepted)
+	})
+
+	g.POST("/:name/pause", func(c echo.Context) error {
+		if err := scheduler.Pause(c.Request().Context(), c.Param("name")); err != nil {
+			return cronError(err)
+		}
+		return cronJobInfo(c, scheduler, http.StatusOK)
+	})
+
+	g.POST("/:name/resume", func(c echo.Context) error {
+		if err := scheduler.Resume(c.Request().Context(), c.Param("name")); err != nil {
+			return cronError(err)
+		}
+		return cronJobInfo(c, scheduler, http.StatusOK)
+	})
+}
+
+func cronRuns(c echo.Context, scheduler *cronJob.Scheduler, name string) error {
+	limit := 50
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return customError.NewBadRequestError("limit must be a positive integer", errorList.InternalErrorList.ValidationError.Code, nil)
+		}
+		limit = n
+	}
+
+	runs, err := scheduler.History(c.Request().Context(), name, limit)
+	if err != nil {
+		return cronError(err)
+	}
+
+	return c.JSON(http.StatusOK, runs)
+}
+
+func cronJobInfo(c echo.Context, scheduler *cronJob.Scheduler, status int) error {
+	info, err := scheduler.Job(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return cronError(err)
+	}
+
+	return c.JSON(status, info)
+}
+
+func cronError(err error) error {
+	if errors.Is(err, cronJob.ErrJobNotFound) {
+		return customError.NewNotFoundErrorWrap(err, "cron job not found", errorList.InternalErrorList.NotFoundError.Code, nil)
+	}
+
+	return customError.NewInternalServerErrorWrap(err, "cron operation failed", errorList.InternalErrorList.InternalServerError.Code, nil)
+}
@@ -3,7 +3,9 @@ package echoHttp
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	sentryEcho "github.com/getsentry/sentry-go/echo"
@@ -11,22 +13,54 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 
-	"github.com/diki-haryadi/ztools/config"
+	"github.com/diki-haryadi/ztools/audit"
 	"github.com/diki-haryadi/ztools/constant"
 	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	cronJob "github.com/diki-haryadi/ztools/cron"
 	echoErrorHandler "github.com/diki-haryadi/ztools/http/echo/handlers/error_handler"
 	"github.com/diki-haryadi/ztools/logger"
+	"github.com/diki-haryadi/ztools/ratelimit"
+	"github.com/diki-haryadi/ztools/redact"
 )
 
 type ServerConfig struct {
 	Port     int
 	BasePath string
 	IsDev    bool
+	AppName  string
+	AppEnv   string
+	// LogBodies logs request and response bodies, redacted, for every request.
+	LogBodies bool
+	// LogLevelPath serves the levels of logger.CurrentLevels with GET and PUT; empty disables it.
+	LogLevelPath string
+	// AdminMiddlewares guard the route of LogLevelPath, e.g. with an auth middleware.
+	AdminMiddlewares []echo.MiddlewareFunc
+	// Auditor records an audit event for the routes in AuditRules; nil disables it.
+	Auditor    *audit.Auditor
+	AuditRules audit.Rules
+	// RateLimiter limits every request to RateLimit per client IP and, when
+	// RateLimitKeyValidator accepts it, per API key of RateLimitHeader; nil disables it.
+	RateLimiter           *ratelimit.Limiter
+	RateLimit             ratelimit.Limit
+	RateLimitHeader       string
+	RateLimitKeyValidator ratelimit.KeyValidator
+	// TrustedProxies are the only peers whose X-Forwarded-For sets the client IP of the
+	// rate limit; without them it is the peer address. c.RealIP is left alone.
+	TrustedProxies []*net.IPNet
+	// ReadTimeout and WriteTimeout bound the body read and the response of every
+	// request, see SetTimeouts; zero disables them.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// IdleTimeout closes idle keep-alive connections.
+	IdleTimeout time.Duration
 }
 
 type Server struct {
 	echo   *echo.Echo
 	config *ServerConfig
+	// readTimeout and writeTimeout change while requests are served.
+	readTimeout  atomic.Int64
+	writeTimeout atomic.Int64
 }
 
 type ServerInterface interface {
@@ -36,10 +70,19 @@ type ServerInterface interface {
 	SetupDefaultMiddlewares()
 	AddMiddlewares(middlewares ...echo.MiddlewareFunc)
 	GetBasePath() string
+	RegisterLogLevelRoute(path string, levels *logger.Levels, m ...echo.MiddlewareFunc)
+	RegisterCronRoutes(path string, scheduler *cronJob.Scheduler, m ...echo.MiddlewareFunc)
+	SetTimeouts(read time.Duration, write time.Duration)
 }
 
 func NewServer(config *ServerConfig) *Server {
-	return &Server{echo: echo.New(), config: config}
+	s := &Server{echo: echo.New(), config: config}
+	s.SetTimeouts(config.ReadTimeout, config.WriteTimeout)
+	if config.LogLevelPath != "" {
+		s.RegisterLogLevelRoute(config.LogLevelPath, logger.CurrentLevels(), config.AdminMiddlewares...)
+	}
+
+	return s
 }
 
 func (s *Server) RunServer(ctx context.Context, configEcho func(echo *echo.Echo)) error {
@@ -55,10 +98,18 @@ func (s *Server) RunServer(ctx context.Context, configEcho func(echo *echo.Echo)
 		}
 	}()
 
+	s.echo.Server.IdleTimeout = s.config.IdleTimeout
 	logger.Zap.Sugar().Infof("[echoServer.RunHttpServer] Echo server is listening on: %d", s.config.Port)
 	return s.echo.Start(fmt.Sprintf(":%d", s.config.Port))
 }
 
+// SetTimeouts changes the read and write timeouts of the next requests, e.g. from a
+// config.Watcher subscription; zero disables them.
+func (s *Server) SetTimeouts(read time.Duration, write time.Duration) {
+	s.readTimeout.Store(int64(read))
+	s.writeTimeout.Store(int64(write))
+}
+
 func (s *Server) AddMiddlewares(middlewares ...echo.MiddlewareFunc) {
 	if len(middlewares) > 0 {
 		s.echo.Use(middlewares...)
@@ -80,15 +131,16 @@ func (s *Server) SetupDefaultMiddlewares() {
 	s.echo.HTTPErrorHandler = echoErrorHandler.ErrorHandler
 
 	s.echo.Use(middleware.Recover())
+	s.echo.Use(s.deadlines)
 	s.echo.Use(sentryEcho.New(sentryEcho.Options{
 		Repanic: true,
 	}))
 	s.echo.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			if hub := sentryEcho.GetHubFromContext(ctx); hub != nil {
-				hub.Scope().SetTag("Application", config.BaseConfig.App.AppName)
+				hub.Scope().SetTag("Application", s.config.AppName)
 				hub.Scope().SetTag("BasePath", s.config.BasePath)
-				hub.Scope().SetTag("AppEnv", config.BaseConfig.App.AppEnv)
+				hub.Scope().SetTag("AppEnv", s.config.AppEnv)
 			}
 			return next(ctx)
 		}
@@ -103,13 +155,13 @@ func (s *Server) SetupDefaultMiddlewares() {
 		LogLatency:   true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
 			t := time.Now()
-			logger.Zap.Info(
+			logger.FromContext(c.Request().Context()).Info(
 				"Incoming Request",
 				zap.String(loggerConstant.TYPE, loggerConstant.HTTP),
 				zap.String(loggerConstant.METHOD, v.Method),
-				zap.String(loggerConstant.REQUEST_ID, v.RequestID),
 				zap.String(loggerConstant.URI, v.URI),
 				zap.String(loggerConstant.STATUS, http.StatusText(v.Status)),
+				zap.Int(loggerConstant.STATUS_CODE, v.Status),
 				zap.Duration(loggerConstant.LATENCY, v.Latency),
 				zap.Time(loggerConstant.TIME, t),
 			)
@@ -118,12 +170,69 @@ func (s *Server) SetupDefaultMiddlewares() {
 	}))
 
 	s.echo.Use(middleware.RequestID())
+	s.echo.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			if span := sentryEcho.GetSpanFromContext(c); span != nil {
+				ctx = span.Context()
+			}
+			fields := append(
+				[]zap.Field{
+					zap.String(loggerConstant.REQUEST_ID, c.Response().Header().Get(echo.HeaderXRequestID)),
+					zap.String(loggerConstant.ROUTE, c.Path()),
+				},
+				logger.TraceFields(ctx)...,
+			)
+			c.SetRequest(c.Request().WithContext(logger.WithContext(ctx, fields...)))
+			return next(c)
+		}
+	})
+	if s.config.RateLimiter != nil {
+		keys := []RateLimitKeyFunc{RateLimitByIP}
+		if len(s.config.TrustedProxies) > 0 {
+			keys[0] = RateLimitByForwardedIP(s.config.TrustedProxies)
+		}
+		if s.config.RateLimitHeader != "" && s.config.RateLimitKeyValidator != nil {
+			keys = append(keys, RateLimitByAPIKey(s.config.RateLimitHeader, s.config.RateLimitKeyValidator))
+		}
+		s.echo.Use(RateLimit(s.config.RateLimiter, s.config.RateLimit, keys...))
+	}
+	if s.config.Auditor != nil && len(s.config.AuditRules) > 0 {
+		s.echo.Use(AuditRoutes(s.config.Auditor, s.config.AuditRules))
+	}
 	s.echo.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level: constant.EchoGzipLevel,
 		// Skipper: func(c echo.Context) bool {
 		//	return strings.Contains(c.Request().URL.Path, "swagger")
 		// },
 	}))
+	// BodyDump sits inside Gzip so it sees the uncompressed response.
+	if s.config.LogBodies {
+		s.echo.Use(middleware.BodyDump(func(c echo.Context, reqBody []byte, resBody []byte) {
+			logger.FromContext(c.Request().Context()).Debug(
+				"Request Body",
+				zap.String(loggerConstant.TYPE, loggerConstant.HTTP),
+				zap.ByteString(loggerConstant.REQUEST, redact.Default().JSON(reqBody)),
+				zap.ByteString(loggerConstant.REPLY, redact.Default().JSON(resBody)),
+			)
+		}))
+	}
+}
+
+// deadlines applies the timeouts of SetTimeouts per request, since the http.Server
+// fields cannot change while it serves.
+func (s *Server) deadlines(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rc := http.NewResponseController(c.Response())
+		now := time.Now()
+		if read := time.Duration(s.readTimeout.Load()); read > 0 {
+			_ = rc.SetReadDeadline(now.Add(read))
+		}
+		if write := time.Duration(s.writeTimeout.Load()); write > 0 {
+			_ = rc.SetWriteDeadline(now.Add(write))
+		}
+		return next(c)
+	}
 }
 
 func (s *Server) GetEchoInstance() *echo.Echo {
@@ -48,14 +48,15 @@ func ErrorHandler(err error, c echo.Context) {
 
 	if !c.Response().Committed {
 		if _, err := httpResponseError.WriteTo(c.Response()); err != nil {
-			logger.Zap.Sugar().Error(`error while writing http error response: %v`, err)
+			logger.FromContext(c.Request().Context()).Sugar().Errorf("error while writing http error response: %v", err)
 		}
-		logger.Zap.Error(
+		logger.FromContext(c.Request().Context()).Error(
 			err.Error(),
 			zap.String(loggerConstant.TYPE, loggerConstant.HTTP),
 			zap.String(loggerConstant.TITILE, httpResponseError.GetTitle()),
 			zap.Int(loggerConstant.CODE, httpResponseError.GetCode()),
 			zap.String(loggerConstant.STATUS, http.StatusText(httpResponseError.GetStatus())),
+			zap.Int(loggerConstant.STATUS_CODE, httpResponseError.GetStatus()),
 			zap.Time(loggerConstant.TIME, httpResponseError.GetTimestamp()),
 			zap.Any(loggerConstant.DETAILS, httpResponseError.GetDetails()),
 			zap.String(loggerConstant.STACK_TRACE, errorUtils.RootStackTrace(err)),
@@ -0,0 +1,38 @@
+package echoHttp
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	errorList "github.com/diki-haryadi/ztools/constant/error/error_list"
+	customError "github.com/diki-haryadi/ztools/error/custom_error"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+type logLevelRequest struct {
+	// Name selects a named logger; an empty Level then removes its override.
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+// RegisterLogLevelRoute serves GET path with the current levels and PUT path with a
+// {"level": "warn"} or {"name": "kafka", "level": "debug"} body to change them. The
+// route is unauthenticated unless m holds an auth middleware.
+func (s *Server) RegisterLogLevelRoute(path string, levels *logger.Levels, m ...echo.MiddlewareFunc) {
+	s.echo.GET(path, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, levels.State())
+	}, m...)
+
+	s.echo.PUT(path, func(c echo.Context) error {
+		req := new(logLevelRequest)
+		if err := c.Bind(req); err != nil {
+			return customError.NewBadRequestErrorWrap(err, "invalid request body", errorList.InternalErrorList.ValidationError.Code, nil)
+		}
+		if err := levels.Update(req.Name, req.Level); err != nil {
+			return customError.NewBadRequestErrorWrap(err, "invalid log level", errorList.InternalErrorList.ValidationError.Code, nil)
+		}
+
+		return c.JSON(http.StatusOK, levels.State())
+	}, m...)
+}
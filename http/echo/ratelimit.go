@@ -0,0 +1,98 @@
+package echoHttp
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	errorList "github.com/diki-haryadi/ztools/constant/error/error_list"
+	httpError "github.com/diki-haryadi/ztools/error/http"
+	"github.com/diki-haryadi/ztools/ratelimit"
+)
+
+// RateLimitKeyFunc selects the bucket of a request; requests with an empty key are
+// not limited.
+type RateLimitKeyFunc func(c echo.Context) string
+
+// RateLimitByIP limits every peer address. Behind a proxy use RateLimitByForwardedIP,
+// X-Forwarded-For is ignored here since any client can send it.
+func RateLimitByIP(c echo.Context) string {
+	return "ip:" + peerHost(c.Request())
+}
+
+// RateLimitByForwardedIP limits every client IP, read from X-Forwarded-For when the
+// peer is one of the trusted proxies, see ratelimit.ClientIP.
+func RateLimitByForwardedIP(trusted []*net.IPNet) RateLimitKeyFunc {
+	return func(c echo.Context) string {
+		req := c.Request()
+		return "ip:" + ratelimit.ClientIP(peerHost(req), req.Header.Values(echo.HeaderXForwardedFor), trusted)
+	}
+}
+
+func peerHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// RateLimitByAPIKey limits every API key sent in header that valid accepts. Requests
+// without a valid key are left to the other keys, e.g. RateLimitByIP.
+func RateLimitByAPIKey(header string, valid ratelimit.KeyValidator) RateLimitKeyFunc {
+	return func(c echo.Context) string {
+		apiKey := c.Request().Header.Get(header)
+		if apiKey == "" || valid == nil || !valid(c.Request().Context(), apiKey) {
+			return ""
+		}
+		return "key:" + ratelimit.HashKey(apiKey)
+	}
+}
+
+// RateLimit counts every request against limit once per key and rejects it with a
+// 429 and Retry-After when any of them is over:
+//
+//	e.POST("/login", h, echoHttp.RateLimit(limiter, ratelimit.Limit{Rate: 5, Period: time.Minute}, echoHttp.RateLimitByIP))
+func RateLimit(limiter *ratelimit.Limiter, limit ratelimit.Limit, keys ...RateLimitKeyFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var res ratelimit.Result
+			limited := false
+			for _, key := range keys {
+				k := key(c)
+				if k == "" {
+					continue
+				}
+				r, err := limiter.Allow(c.Request().Context(), k, limit)
+				if err != nil {
+					return err
+				}
+				// The headers describe the tightest key.
+				if !limited || !r.Allowed || r.Remaining < res.Remaining {
+					res, limited = r, true
+				}
+				if !r.Allowed {
+					break
+				}
+			}
+			if !limited {
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set(ratelimit.HeaderLimit, strconv.Itoa(res.Limit))
+			h.Set(ratelimit.HeaderRemaining, strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				retryAfter := strconv.Itoa(res.RetryAfterSeconds())
+				h.Set(ratelimit.HeaderRetryAfter, retryAfter)
+				tooManyRequests := errorList.InternalErrorList.TooManyRequests
+				return httpError.NewHttpTooManyRequestsError(tooManyRequests.Code, tooManyRequests.Msg, map[string]string{"retryAfter": retryAfter})
+			}
+
+			return next(c)
+		}
+	}
+}
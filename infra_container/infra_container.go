@@ -6,39 +6,126 @@ import (
 	"time"
 
 	sentry "github.com/getsentry/sentry-go"
+	goRedis "github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
 	kafka "github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
+	"github.com/diki-haryadi/ztools/audit"
 	"github.com/diki-haryadi/ztools/config"
+	"github.com/diki-haryadi/ztools/constant"
+	cronJob "github.com/diki-haryadi/ztools/cron"
+	externalBridge "github.com/diki-haryadi/ztools/external_bridge"
 	"github.com/diki-haryadi/ztools/grpc"
 	echoHttp "github.com/diki-haryadi/ztools/http/echo"
 	kafkaConsumer "github.com/diki-haryadi/ztools/kafka/consumer"
 	kafkaProducer "github.com/diki-haryadi/ztools/kafka/producer"
 	"github.com/diki-haryadi/ztools/logger"
 	"github.com/diki-haryadi/ztools/postgres"
+	"github.com/diki-haryadi/ztools/ratelimit"
+	"github.com/diki-haryadi/ztools/redis"
+	sentryUtils "github.com/diki-haryadi/ztools/sentry/sentry_utils"
 )
 
 type IContainer struct {
 	Config         *config.Config
+	ConfigWatcher  *config.Watcher
 	Logger         *zap.Logger
 	Postgres       *postgres.Postgres
+	Redis          goRedis.UniversalClient
 	GrpcServer     grpc.Server
 	EchoHttpServer echoHttp.ServerInterface
 	KafkaWriter    *kafkaProducer.Writer
 	KafkaReader    *kafkaConsumer.Reader
-	DownFns        []func()
-	Down           func()
-	Context        context.Context
+	ExternalBridge *externalBridge.ExternalBridge
+	Auditor        *audit.Auditor
+	AuditRules     audit.Rules
+	// AdminMiddlewares guard the log level and cron admin routes of the echo server.
+	AdminMiddlewares []echo.MiddlewareFunc
+	Cron             *cronJob.Scheduler
+	CronLocker       cronJob.Locker
+	RateLimiter      *ratelimit.Limiter
+	// RateLimitKeyValidator accepts the API keys limited on their own.
+	RateLimitKeyValidator ratelimit.KeyValidator
+	DownFns               []func()
+	Down                  func()
+	Context               context.Context
 }
 
 func (ic *IContainer) IContext(ctx context.Context) *IContainer {
 	ic.Context = ctx
+	return ic
+}
+
+// IConfig sets the configuration used by the builders. Without it they fall back
+// to config.Current.
+func (ic *IContainer) IConfig(cfg *config.Config) *IContainer {
+	ic.Config = cfg
+	return ic
+}
+
+// IAuditRules sets the echo routes and gRPC methods audited by ICGrpc and ICEcho.
+// It must run before them, after ICAudit.
+func (ic *IContainer) IAuditRules(rules audit.Rules) *IContainer {
+	ic.AuditRules = rules
+	return ic
+}
+
+// IAdminMiddlewares guards the admin routes registered by ICEcho and ICCron, e.g.
+// with an auth middleware. It must run before them.
+func (ic *IContainer) IAdminMiddlewares(m ...echo.MiddlewareFunc) *IContainer {
+	ic.AdminMiddlewares = m
+	return ic
+}
+
+// IRateLimitKeyValidator lets ICGrpc and ICEcho limit every API key that valid
+// accepts, on top of the client IP. It must run before them.
+func (ic *IContainer) IRateLimitKeyValidator(valid ratelimit.KeyValidator) *IContainer {
+	ic.RateLimitKeyValidator = valid
+	return ic
+}
+
+func (ic *IContainer) conf() *config.Config {
+	if ic.Config == nil {
+		ic.Config = config.Current()
+	}
+	return ic.Config
+}
+
+func (ic *IContainer) profile() config.Profile {
+	return ic.conf().Profile()
+}
+
+// ICConfigWatcher reloads the configuration on SIGHUP or file change for the lifetime
+// of the container context. It must run before the builders that subscribe to it.
+func (ic *IContainer) ICConfigWatcher(opts config.WatcherOptions) *IContainer {
+	if ic.Context == nil {
+		ic.Context = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ic.Context)
+	ic.ConfigWatcher = config.NewWatcher(opts)
+	ic.ConfigWatcher.Start(ctx)
+	ic.DownFns = append(ic.DownFns, cancel)
+	unsubscribe := ic.ConfigWatcher.OnLogChange(func(_ config.LogConfig, logConf config.LogConfig) {
+		level, err := zapcore.ParseLevel(logConf.Level)
+		if err != nil {
+			return
+		}
+		named, err := logger.ParseLevels(logConf.Levels)
+		if err != nil {
+			return
+		}
+		logger.CurrentLevels().Apply(level, named)
+	})
+	ic.DownFns = append(ic.DownFns, unsubscribe)
+	return ic
 }
+
 func (ic *IContainer) ICDown() *IContainer {
-	var downFns []func()
 	down := func() {
-		for _, df := range downFns {
-			df()
+		for i := len(ic.DownFns) - 1; i >= 0; i-- {
+			ic.DownFns[i]()
 		}
 	}
 	ic.Down = down
@@ -46,13 +133,17 @@ func (ic *IContainer) ICDown() *IContainer {
 }
 
 func (ic *IContainer) ICPostgres() *IContainer {
+	pgConf := ic.conf().Postgres
 	pg, err := postgres.NewConnection(context.Background(), &postgres.Config{
-		Host:    config.BaseConfig.Postgres.Host,
-		Port:    config.BaseConfig.Postgres.Port,
-		User:    config.BaseConfig.Postgres.User,
-		Pass:    config.BaseConfig.Postgres.Pass,
-		DBName:  config.BaseConfig.Postgres.DBName,
-		SslMode: config.BaseConfig.Postgres.SslMode,
+		Host:            pgConf.Host,
+		Port:            pgConf.Port,
+		User:            pgConf.User,
+		Pass:            pgConf.Pass,
+		DBName:          pgConf.DBName,
+		SslMode:         pgConf.SslMode,
+		MaxConn:         pgConf.MaxConn,
+		MaxIdleConn:     pgConf.MaxIdleConn,
+		MaxLifeTimeConn: pgConf.MaxLifeTimeConn,
 	})
 	if err != nil {
 		return nil
@@ -61,14 +152,71 @@ func (ic *IContainer) ICPostgres() *IContainer {
 	ic.DownFns = append(ic.DownFns, func() {
 		ic.Postgres.Close()
 	})
+	if ic.ConfigWatcher != nil {
+		unsubscribe := ic.ConfigWatcher.OnPostgresChange(func(_ config.PostgresConfig, pgConf config.PostgresConfig) {
+			ic.Postgres.SetPool(pgConf.MaxConn, pgConf.MaxIdleConn, pgConf.MaxLifeTimeConn)
+		})
+		ic.DownFns = append(ic.DownFns, unsubscribe)
+	}
+	return ic
+}
+
+// ICRedis connects to the standalone, Sentinel or Cluster Redis of the Redis section
+// and pings it on startup.
+func (ic *IContainer) ICRedis() *IContainer {
+	redisConf := ic.conf().Redis
+	client := *redis.NewUniversalRedisClient(&redis.Config{
+		Mode:             redisConf.Mode,
+		Addrs:            redisConf.Addrs,
+		MasterName:       redisConf.MasterName,
+		Username:         redisConf.Username,
+		Password:         redisConf.Password,
+		SentinelPassword: redisConf.SentinelPassword,
+		DB:               redisConf.DB,
+		TLS:              redisConf.TLS,
+		PoolSize:         redisConf.PoolSize,
+		MinIdleConns:     redisConf.MinIdleConns,
+		MaxRetries:       redisConf.MaxRetries,
+		MinRetryBackoff:  redisConf.MinRetryBackoff,
+		MaxRetryBackoff:  redisConf.MaxRetryBackoff,
+		DialTimeout:      redisConf.DialTimeout,
+		ReadTimeout:      redisConf.ReadTimeout,
+		WriteTimeout:     redisConf.WriteTimeout,
+		PoolTimeout:      redisConf.PoolTimeout,
+		IdleTimeout:      redisConf.IdleTimeout,
+	})
+	if err := redis.Ping(context.Background(), client, redisConf.DialTimeout); err != nil {
+		_ = client.Close()
+		return nil
+	}
+	ic.Redis = client
+	ic.DownFns = append(ic.DownFns, func() {
+		_ = ic.Redis.Close()
+	})
 	return ic
 }
 
 func (ic *IContainer) ICGrpc() *IContainer {
 	grpcServerConfig := &grpc.Config{
-		Port:        config.BaseConfig.Grpc.Port,
-		Host:        config.BaseConfig.Grpc.Host,
-		Development: config.IsDevEnv(),
+		Port:          ic.conf().Grpc.Port,
+		Host:          ic.conf().Grpc.Host,
+		Development:   ic.profile().GrpcReflection,
+		AppName:       ic.conf().App.AppName,
+		AppEnv:        ic.conf().App.AppEnv,
+		LogLevelAdmin: ic.conf().Log.AdminGrpc,
+		Auditor:       ic.Auditor,
+		AuditRules:    ic.AuditRules,
+	}
+	if ic.RateLimiter != nil {
+		trustedProxies, err := ratelimit.ParseTrustedProxies(ic.conf().RateLimit.TrustedProxies)
+		if err != nil {
+			return nil
+		}
+		grpcServerConfig.RateLimiter = ic.RateLimiter
+		grpcServerConfig.RateLimit = ic.rateLimit()
+		grpcServerConfig.RateLimitHeader = ic.conf().RateLimit.APIKeyHeader
+		grpcServerConfig.RateLimitKeyValidator = ic.RateLimitKeyValidator
+		grpcServerConfig.TrustedProxies = trustedProxies
 	}
 	ic.GrpcServer = grpc.NewGrpcServer(grpcServerConfig)
 	ic.DownFns = append(ic.DownFns, func() {
@@ -79,44 +227,224 @@ func (ic *IContainer) ICGrpc() *IContainer {
 
 func (ic *IContainer) ICEcho() *IContainer {
 	echoServerConfig := &echoHttp.ServerConfig{
-		Port:     config.BaseConfig.Http.Port,
-		BasePath: "/api/v1",
-		IsDev:    config.IsDevEnv(),
+		Port:             ic.conf().Http.Port,
+		BasePath:         "/api/v1",
+		IsDev:            ic.profile().Development,
+		AppName:          ic.conf().App.AppName,
+		AppEnv:           ic.conf().App.AppEnv,
+		LogBodies:        ic.conf().Http.LogBodies,
+		LogLevelPath:     ic.conf().Log.AdminPath,
+		AdminMiddlewares: ic.AdminMiddlewares,
+		Auditor:          ic.Auditor,
+		AuditRules:       ic.AuditRules,
+		ReadTimeout:      ic.conf().Http.ReadTimeout,
+		WriteTimeout:     ic.conf().Http.WriteTimeout,
+		IdleTimeout:      ic.conf().Http.IdleTimeout,
+	}
+	if ic.RateLimiter != nil {
+		trustedProxies, err := ratelimit.ParseTrustedProxies(ic.conf().RateLimit.TrustedProxies)
+		if err != nil {
+			return nil
+		}
+		echoServerConfig.RateLimiter = ic.RateLimiter
+		echoServerConfig.RateLimit = ic.rateLimit()
+		echoServerConfig.RateLimitHeader = ic.conf().RateLimit.APIKeyHeader
+		echoServerConfig.RateLimitKeyValidator = ic.RateLimitKeyValidator
+		echoServerConfig.TrustedProxies = trustedProxies
 	}
 	ic.EchoHttpServer = echoHttp.NewServer(echoServerConfig)
 	ic.EchoHttpServer.SetupDefaultMiddlewares()
 	ic.DownFns = append(ic.DownFns, func() {
 		_ = ic.EchoHttpServer.GracefulShutdown(context.Background())
 	})
+	if ic.ConfigWatcher != nil {
+		unsubscribe := ic.ConfigWatcher.OnHttpChange(func(_ config.HttpConfig, httpConf config.HttpConfig) {
+			ic.EchoHttpServer.SetTimeouts(httpConf.ReadTimeout, httpConf.WriteTimeout)
+		})
+		ic.DownFns = append(ic.DownFns, unsubscribe)
+	}
 	return ic
 }
 
 func (ic *IContainer) ICKafka() *IContainer {
-	var kw *kafkaProducer.Writer
-	var kr *kafkaConsumer.Reader
+	kafkaConf := ic.conf().Kafka
 
 	kwc := &kafkaProducer.WriterConfig{
-		Brokers:      config.BaseConfig.Kafka.ClientBrokers,
-		Topic:        config.BaseConfig.Kafka.Topic,
+		Brokers:      kafkaConf.ClientBrokers,
+		Topic:        kafkaConf.Topic,
 		RequiredAcks: kafka.RequireAll,
 	}
-	ic.KafkaWriter = kafkaProducer.NewKafkaWriter(kwc)
+	kw := kafkaProducer.NewKafkaWriter(kwc)
+	ic.KafkaWriter = kw
 	ic.DownFns = append(ic.DownFns, func() {
 		_ = kw.Client.Close()
 	})
 
 	krc := &kafkaConsumer.ReaderConfig{
-		Brokers: config.BaseConfig.Kafka.ClientBrokers,
-		Topic:   config.BaseConfig.Kafka.Topic,
-		GroupID: config.BaseConfig.Kafka.ClientGroupId,
+		Brokers: kafkaConf.ClientBrokers,
+		Topic:   kafkaConf.Topic,
+		GroupID: kafkaConf.ClientGroupId,
 	}
-	ic.KafkaReader = kafkaConsumer.NewKafkaReader(krc)
+	kr := kafkaConsumer.NewKafkaReader(krc)
+	ic.KafkaReader = kr
 	ic.DownFns = append(ic.DownFns, func() {
 		_ = kr.Client.Close()
 	})
 	return ic
 }
 
+func (ic *IContainer) ICExternalBridge() *IContainer {
+	if ic.Context == nil {
+		ic.Context = context.Background()
+	}
+	eb, down, err := externalBridge.NewExternalBridge(ic.Context, ic.conf())
+	if err != nil {
+		return nil
+	}
+	ic.ExternalBridge = eb
+	ic.DownFns = append(ic.DownFns, down)
+	return ic
+}
+
+// ICAudit opens the audit sink of the Audit section. With the kafka sink it needs
+// the Kafka section for the brokers.
+func (ic *IContainer) ICAudit() *IContainer {
+	auditConf := ic.conf().Audit
+	if !auditConf.Enabled {
+		return ic
+	}
+
+	var sink audit.Sink
+	switch auditConf.Sink {
+	case constant.AuditSinkKafka:
+		kw := kafkaProducer.NewKafkaWriter(&kafkaProducer.WriterConfig{
+			Brokers:      ic.conf().Kafka.ClientBrokers,
+			Topic:        auditConf.Topic,
+			RequiredAcks: kafka.RequireAll,
+			Balancer:     &kafka.Hash{},
+		})
+		sink = audit.NewKafkaSink(kw, ic.conf().App.AppName)
+	default:
+		fs, err := audit.NewFileSink(logger.FileSinkOptions{
+			Dir:      auditConf.Dir,
+			Filename: auditConf.File,
+			MaxSize:  int64(auditConf.MaxSize),
+		})
+		if err != nil {
+			return nil
+		}
+		sink = fs
+	}
+
+	auditor, err := audit.New(sink, audit.Options{Key: []byte(auditConf.HashKey)})
+	if err != nil {
+		_ = sink.Close()
+		return nil
+	}
+	ic.Auditor = auditor
+	ic.DownFns = append(ic.DownFns, func() {
+		_ = ic.Auditor.Close()
+	})
+	return ic
+}
+
+// ICRateLimit builds the limiter of the RateLimit section for ICGrpc and ICEcho, so
+// it must run before them. It limits in Redis when ICRedis ran first.
+func (ic *IContainer) ICRateLimit() *IContainer {
+	rateLimitConf := ic.conf().RateLimit
+	if !rateLimitConf.Enabled {
+		return ic
+	}
+
+	ic.RateLimiter = ratelimit.New(ic.Redis, ratelimit.Options{
+		Algorithm:     rateLimitConf.Algorithm,
+		Prefix:        ic.conf().App.AppName + ":",
+		RedisTimeout:  rateLimitConf.RedisTimeout,
+		RedisCooldown: rateLimitConf.RedisCooldown,
+	})
+	return ic
+}
+
+func (ic *IContainer) rateLimit() ratelimit.Limit {
+	rateLimitConf := ic.conf().RateLimit
+	return ratelimit.Limit{
+		Rate:   rateLimitConf.Rate,
+		Period: rateLimitConf.Period,
+		Burst:  rateLimitConf.Burst,
+	}
+}
+
+// ICCronLocker makes the jobs of ICCron run once per schedule across the replicas
+// sharing locker, e.g. cronJob.NewRedisLocker(ic.Redis). It must run before ICCron.
+func (ic *IContainer) ICCronLocker(locker cronJob.Locker) *IContainer {
+	ic.CronLocker = locker
+	return ic
+}
+
+// ICCron starts a job scheduler for the lifetime of the container context; jobs are
+// registered on ic.Cron before or after. The Postgres history and pauses need
+// ICPostgres, the Redis pauses ICRedis and the admin routes ICEcho to run first.
+func (ic *IContainer) ICCron() *IContainer {
+	if ic.Context == nil {
+		ic.Context = context.Background()
+	}
+	cronConf := ic.conf().Cron
+
+	var history cronJob.HistoryStore = cronJob.NewMemoryHistory(cronConf.HistorySize)
+	if cronConf.History == constant.CronHistoryPostgres {
+		if ic.Postgres == nil {
+			return nil
+		}
+		pgHistory, err := cronJob.NewPostgresHistory(ic.Postgres, cronConf.HistoryTable)
+		if err != nil {
+			return nil
+		}
+		if err := pgHistory.Migrate(ic.Context); err != nil {
+			return nil
+		}
+		history = pgHistory
+	}
+
+	var pauses cronJob.PauseStore
+	switch cronConf.Pauses {
+	case constant.CronPausesRedis:
+		if ic.Redis == nil {
+			return nil
+		}
+		pauses = cronJob.NewRedisPauses(ic.Redis, ic.conf().App.AppName+":cron:paused")
+	case constant.CronPausesPostgres:
+		if ic.Postgres == nil {
+			return nil
+		}
+		pgPauses, err := cronJob.NewPostgresPauses(ic.Postgres, cronConf.PauseTable)
+		if err != nil {
+			return nil
+		}
+		if err := pgPauses.Migrate(ic.Context); err != nil {
+			return nil
+		}
+		pauses = pgPauses
+	}
+
+	ic.Cron = cronJob.NewScheduler(cronJob.Options{
+		Sentry: &sentryUtils.Options{
+			Tags: sentryUtils.AppTags(ic.conf().App.AppName, ic.conf().App.AppEnv),
+		},
+		Locker:     ic.CronLocker,
+		LockPrefix: ic.conf().App.AppName + ":",
+		History:    history,
+		Pauses:     pauses,
+	})
+	ic.Cron.Start(ic.Context)
+	if ic.EchoHttpServer != nil && cronConf.AdminPath != "" {
+		ic.EchoHttpServer.RegisterCronRoutes(cronConf.AdminPath, ic.Cron, ic.AdminMiddlewares...)
+	}
+	ic.DownFns = append(ic.DownFns, func() {
+		<-ic.Cron.Stop().Done()
+	})
+	return ic
+}
+
 func (ic *IContainer) NewIC() (*IContainer, func(), error) {
 	//var downFns []func()
 	//down := func() {
@@ -125,9 +453,10 @@ func (ic *IContainer) NewIC() (*IContainer, func(), error) {
 	//	}
 	//}
 	se := sentry.Init(sentry.ClientOptions{
-		Dsn:              config.BaseConfig.Sentry.Dsn,
-		TracesSampleRate: 1.0,
-		EnableTracing:    config.IsDevEnv(),
+		Dsn:              ic.conf().Sentry.Dsn,
+		TracesSampleRate: ic.profile().SentryTracesSampleRate,
+		EnableTracing:    ic.profile().SentryTracing,
+		BeforeSend:       sentryUtils.RedactEvent,
 	})
 	if se != nil {
 		_ = fmt.Errorf("can not initialize sentry with error:  %s", se)
@@ -198,13 +527,23 @@ func (ic *IContainer) NewIC() (*IContainer, func(), error) {
 	//}
 
 	nic := &IContainer{
-		Config:         config.BaseConfig,
-		Logger:         logger.Zap,
-		Postgres:       ic.Postgres,
-		GrpcServer:     ic.GrpcServer,
-		EchoHttpServer: ic.EchoHttpServer,
-		KafkaWriter:    ic.KafkaWriter,
-		KafkaReader:    ic.KafkaReader,
+		Config:                ic.conf(),
+		ConfigWatcher:         ic.ConfigWatcher,
+		Logger:                logger.Zap,
+		Postgres:              ic.Postgres,
+		Redis:                 ic.Redis,
+		GrpcServer:            ic.GrpcServer,
+		EchoHttpServer:        ic.EchoHttpServer,
+		KafkaWriter:           ic.KafkaWriter,
+		KafkaReader:           ic.KafkaReader,
+		ExternalBridge:        ic.ExternalBridge,
+		Auditor:               ic.Auditor,
+		AuditRules:            ic.AuditRules,
+		AdminMiddlewares:      ic.AdminMiddlewares,
+		Cron:                  ic.Cron,
+		CronLocker:            ic.CronLocker,
+		RateLimiter:           ic.RateLimiter,
+		RateLimitKeyValidator: ic.RateLimitKeyValidator,
 	}
 
 	return nic, ic.Down, nil
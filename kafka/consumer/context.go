@@ -0,0 +1,31 @@
+package kafkaConsumer
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// WithMessage stores a logger carrying the topic, partition and offset of msg in ctx
+// so every line logged while handling the message can be correlated.
+func WithMessage(ctx context.Context, msg kafka.Message) context.Context {
+	return logger.WithContext(ctx,
+		zap.String(loggerConstant.TOPIC, msg.Topic),
+		zap.Int(loggerConstant.PARTITION, msg.Partition),
+		zap.Int64(loggerConstant.OFFSET, msg.Offset),
+	)
+}
+
+// FetchMessage fetches the next message and returns it with a context built by WithMessage.
+func (r *Reader) FetchMessage(ctx context.Context) (context.Context, kafka.Message, error) {
+	msg, err := r.Client.FetchMessage(ctx)
+	if err != nil {
+		return ctx, msg, err
+	}
+
+	return WithMessage(ctx, msg), msg, nil
+}
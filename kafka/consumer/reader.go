@@ -6,6 +6,9 @@ import (
 	"github.com/diki-haryadi/ztools/logger"
 )
 
+// loggerName lets LOG_LEVELS=kafka=debug change the level of the Kafka client logs only.
+const loggerName = "kafka"
+
 type Reader struct {
 	Client *kafka.Reader
 }
@@ -21,8 +24,8 @@ func NewKafkaReader(cfg *ReaderConfig) *Reader {
 		Brokers:     cfg.Brokers,
 		GroupID:     cfg.GroupID,
 		Topic:       cfg.Topic,
-		Logger:      kafka.LoggerFunc(logger.Zap.Sugar().Infof),
-		ErrorLogger: kafka.LoggerFunc(logger.Zap.Sugar().Errorf),
+		Logger:      kafka.LoggerFunc(logger.Zap.Named(loggerName).Sugar().Infof),
+		ErrorLogger: kafka.LoggerFunc(logger.Zap.Named(loggerName).Sugar().Errorf),
 	}
 
 	return &Reader{
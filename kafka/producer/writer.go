@@ -7,6 +7,9 @@ import (
 	"github.com/diki-haryadi/ztools/logger"
 )
 
+// loggerName lets LOG_LEVELS=kafka=debug change the level of the Kafka client logs only.
+const loggerName = "kafka"
+
 type Writer struct {
 	Client *kafka.Writer
 }
@@ -15,17 +18,24 @@ type WriterConfig struct {
 	Brokers      []string
 	Topic        string
 	RequiredAcks kafka.RequiredAcks
+	// Balancer picks the partition of a message, kafka.LeastBytes when nil. Use
+	// kafka.Hash to keep messages with the same key in order on one partition.
+	Balancer kafka.Balancer
 }
 
 func NewKafkaWriter(cfg *WriterConfig) *Writer {
+	balancer := cfg.Balancer
+	if balancer == nil {
+		balancer = &kafka.LeastBytes{}
+	}
 	kafkaWriterConfig := &kafka.Writer{
 		Addr:         kafka.TCP(cfg.Brokers...),
 		Topic:        cfg.Topic,
 		RequiredAcks: cfg.RequiredAcks,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     balancer,
 		Compression:  compress.Snappy,
-		Logger:       kafka.LoggerFunc(logger.Zap.Sugar().Infof),
-		ErrorLogger:  kafka.LoggerFunc(logger.Zap.Sugar().Errorf),
+		Logger:       kafka.LoggerFunc(logger.Zap.Named(loggerName).Sugar().Infof),
+		ErrorLogger:  kafka.LoggerFunc(logger.Zap.Named(loggerName).Sugar().Errorf),
 	}
 	return &Writer{
 		Client: kafkaWriterConfig,
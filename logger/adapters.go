@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zapgrpc"
+	"google.golang.org/grpc/grpclog"
+)
+
+const (
+	grpcLoggerName  = "grpc"
+	redisLoggerName = "redis"
+)
+
+var (
+	adaptersMu     sync.Mutex
+	restoreStdLog  func()
+	grpcLoggerOnce sync.Once
+	grpcDelegate   = &swapGrpcLogger{}
+)
+
+// NewGrpcLogger returns a grpclog.LoggerV2 for the gRPC internals, named "grpc" so
+// LOG_LEVELS=grpc=warn quiets them.
+func NewGrpcLogger(l *zap.Logger) grpclog.LoggerV2 {
+	return zapgrpc.NewLogger(l.Named(grpcLoggerName))
+}
+
+// RedisLogger implements the internal logger of go-redis. Its messages are about
+// pool and connection failures, so they are written as warnings.
+type RedisLogger struct {
+	logger *zap.Logger
+}
+
+func NewRedisLogger(l *zap.Logger) *RedisLogger {
+	return &RedisLogger{logger: l.Named(redisLoggerName)}
+}
+
+func (rl *RedisLogger) Printf(ctx context.Context, format string, v ...interface{}) {
+	l := rl.logger
+	if ctxLogger, ok := loggerFromContext(ctx); ok {
+		l = ctxLogger.Named(redisLoggerName)
+	}
+	l.Warn(fmt.Sprintf(format, v...))
+}
+
+// InstallAdapters routes log/slog, the standard log package, the gRPC internals and
+// the go-redis internals through l. NewLogger calls it with the global logger.
+func InstallAdapters(l *zap.Logger) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+
+	// slog.SetDefault also redirects the standard log package, so redirect it after.
+	slog.SetDefault(slog.New(NewSlogHandler(l)))
+	if restoreStdLog != nil {
+		restoreStdLog()
+	}
+	// The standard log package carries log.Fatal and the config reload failures, so
+	// it logs at error level, which is never filtered by level nor sampled.
+	// RedirectStdLogAt only fails on levels it cannot log at.
+	restoreStdLog, _ = zap.RedirectStdLogAt(l, zapcore.ErrorLevel)
+
+	// grpclog.SetLoggerV2 is not safe once gRPC is running, so it is called once with a
+	// delegate that follows the latest logger.
+	grpcDelegate.current.Store(zapgrpc.NewLogger(l.Named(grpcLoggerName)))
+	grpcLoggerOnce.Do(func() {
+		grpclog.SetLoggerV2(grpcDelegate)
+	})
+
+	redis.SetLogger(NewRedisLogger(l))
+}
+
+type swapGrpcLogger struct {
+	current atomic.Pointer[zapgrpc.Logger]
+}
+
+func (sl *swapGrpcLogger) Info(args ...interface{}) {
+	sl.current.Load().Info(args...)
+}
+
+func (sl *swapGrpcLogger) Infoln(args ...interface{}) {
+	sl.current.Load().Infoln(args...)
+}
+
+func (sl *swapGrpcLogger) Infof(format string, args ...interface{}) {
+	sl.current.Load().Infof(format, args...)
+}
+
+func (sl *swapGrpcLogger) Warning(args ...interface{}) {
+	sl.current.Load().Warning(args...)
+}
+
+func (sl *swapGrpcLogger) Warningln(args ...interface{}) {
+	sl.current.Load().Warningln(args...)
+}
+
+func (sl *swapGrpcLogger) Warningf(format string, args ...interface{}) {
+	sl.current.Load().Warningf(format, args...)
+}
+
+func (sl *swapGrpcLogger) Error(args ...interface{}) {
+	sl.current.Load().Error(args...)
+}
+
+func (sl *swapGrpcLogger) Errorln(args ...interface{}) {
+	sl.current.Load().Errorln(args...)
+}
+
+func (sl *swapGrpcLogger) Errorf(format string, args ...interface{}) {
+	sl.current.Load().Errorf(format, args...)
+}
+
+func (sl *swapGrpcLogger) Fatal(args ...interface{}) {
+	sl.current.Load().Fatal(args...)
+}
+
+func (sl *swapGrpcLogger) Fatalln(args ...interface{}) {
+	sl.current.Load().Fatalln(args...)
+}
+
+func (sl *swapGrpcLogger) Fatalf(format string, args ...interface{}) {
+	sl.current.Load().Fatalf(format, args...)
+}
+
+func (sl *swapGrpcLogger) V(level int) bool {
+	return sl.current.Load().V(level)
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap"
+
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+)
+
+type ctxKey struct{}
+
+// FromContext returns the logger stored by WithContext, or the global Zap logger.
+func FromContext(ctx context.Context) *zap.Logger {
+	return fromContextOr(ctx, Zap)
+}
+
+// fromContextOr is FromContext with the logger of an adapter as fallback.
+func fromContextOr(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if l, ok := loggerFromContext(ctx); ok {
+		return l
+	}
+	if fallback == nil {
+		return zap.NewNop()
+	}
+
+	return fallback
+}
+
+func loggerFromContext(ctx context.Context) (*zap.Logger, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	l, ok := ctx.Value(ctxKey{}).(*zap.Logger)
+
+	return l, ok
+}
+
+// WithContext stores a child of FromContext(ctx) carrying fields, so every line
+// logged through FromContext during a request shares them.
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	return context.WithValue(ctx, ctxKey{}, FromContext(ctx).With(fields...))
+}
+
+// TraceFields returns the trace and span IDs of the Sentry span in ctx, if any.
+func TraceFields(ctx context.Context) []zap.Field {
+	span := sentry.SpanFromContext(ctx)
+	if span == nil {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String(loggerConstant.TRACE_ID, span.TraceID.String()),
+		zap.String(loggerConstant.SPAN_ID, span.SpanID.String()),
+	}
+}
@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the base level of a logger and overrides for named loggers, e.g.
+// debug for logger.Zap.Named("kafka") only. An override applies to the named logger
+// and its children ("kafka.consumer").
+type Levels struct {
+	base zap.AtomicLevel
+
+	mu    sync.RWMutex
+	named map[string]zapcore.Level
+	// order holds the names of named, longest first, so For matches the longest prefix.
+	order []string
+	// min is the lowest enabled level across base and overrides.
+	min zap.AtomicLevel
+}
+
+var currentLevels = NewLevels(zapcore.DebugLevel)
+
+// CurrentLevels returns the levels of the logger built by NewLogger.
+func CurrentLevels() *Levels {
+	return currentLevels
+}
+
+func NewLevels(level zapcore.Level) *Levels {
+	return &Levels{
+		base:  zap.NewAtomicLevelAt(level),
+		named: map[string]zapcore.Level{},
+		min:   zap.NewAtomicLevelAt(level),
+	}
+}
+
+func (ls *Levels) Level() zapcore.Level {
+	return ls.base.Level()
+}
+
+func (ls *Levels) SetLevel(level zapcore.Level) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.base.SetLevel(level)
+	ls.update()
+}
+
+func (ls *Levels) SetNamed(name string, level zapcore.Level) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.named[name] = level
+	ls.update()
+}
+
+func (ls *Levels) ResetNamed(name string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(ls.named, name)
+	ls.update()
+}
+
+// Named returns a copy of the overrides.
+func (ls *Levels) Named() map[string]zapcore.Level {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	named := make(map[string]zapcore.Level, len(ls.named))
+	for k, v := range ls.named {
+		named[k] = v
+	}
+
+	return named
+}
+
+// Apply replaces the base level and every override, e.g. after a config reload.
+func (ls *Levels) Apply(level zapcore.Level, named map[string]zapcore.Level) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.base.SetLevel(level)
+	ls.named = make(map[string]zapcore.Level, len(named))
+	for k, v := range named {
+		ls.named[k] = v
+	}
+	ls.update()
+}
+
+// For returns the level of the named logger: the override of its longest matching
+// dotted prefix, or the base level.
+func (ls *Levels) For(name string) zapcore.Level {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	for _, n := range ls.order {
+		if name == n || strings.HasPrefix(name, n+".") {
+			return ls.named[n]
+		}
+	}
+
+	return ls.base.Level()
+}
+
+// update recomputes order and min after a change; callers hold mu.
+func (ls *Levels) update() {
+	min := ls.base.Level()
+	order := make([]string, 0, len(ls.named))
+	for n, l := range ls.named {
+		if l < min {
+			min = l
+		}
+		order = append(order, n)
+	}
+	sort.Slice(order, func(i, j int) bool { return len(order[i]) > len(order[j]) })
+	ls.order = order
+	ls.min.SetLevel(min)
+}
+
+// ParseLevels parses overrides such as {"kafka": "debug"}.
+func ParseLevels(named map[string]string) (map[string]zapcore.Level, error) {
+	levels := make(map[string]zapcore.Level, len(named))
+	for name, text := range named {
+		level, err := zapcore.ParseLevel(text)
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = level
+	}
+
+	return levels, nil
+}
+
+// levelCore filters entries by the level of the logger that wrote them. The wrapped
+// cores must enable every level; only levelCore decides.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func (lc *levelCore) Enabled(level zapcore.Level) bool {
+	return lc.levels.min.Enabled(level)
+}
+
+func (lc *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: lc.Core.With(fields), levels: lc.levels}
+}
+
+func (lc *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < lc.levels.For(ent.LoggerName) {
+		return ce
+	}
+
+	return lc.Core.Check(ent, ce)
+}
+
+// LevelState is the wire form of Levels used by the admin endpoints.
+type LevelState struct {
+	Level string            `json:"level"`
+	Named map[string]string `json:"named,omitempty"`
+}
+
+func (ls *Levels) State() LevelState {
+	state := LevelState{Level: ls.Level().String()}
+	for name, level := range ls.Named() {
+		if state.Named == nil {
+			state.Named = map[string]string{}
+		}
+		state.Named[name] = level.String()
+	}
+
+	return state
+}
+
+// Update sets the base level, or the override of name when name is set. An empty
+// level removes the override of name.
+func (ls *Levels) Update(name string, level string) error {
+	if name != "" && level == "" {
+		ls.ResetNamed(name)
+		return nil
+	}
+
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		ls.SetLevel(lvl)
+	} else {
+		ls.SetNamed(name, lvl)
+	}
+
+	return nil
+}
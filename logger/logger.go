@@ -3,84 +3,279 @@ package logger
 import (
 	"log"
 	"os"
-	"path/filepath"
-	"runtime"
+	"sync"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"github.com/diki-haryadi/ztools/config"
+	"github.com/diki-haryadi/ztools/constant"
+	"github.com/diki-haryadi/ztools/redact"
 )
 
 var Zap *zap.Logger
 
+var (
+	closeMu   sync.Mutex
+	closeSink func() error
+)
+
 func init() {
 	//Zap = NewLogger()
 }
 
+type Options struct {
+	// Sinks combines constant.LogSinkConsole, LogSinkStdout, LogSinkStderr and LogSinkFile.
+	// When empty, Production selects the file sink and console logs otherwise.
+	Sinks      []string
+	Production bool
+	// LogDir is kept for callers that only set the directory; File.Dir takes precedence.
+	LogDir string
+	File   FileSinkOptions
+	// Level is the base level, debug when empty. NamedLevels overrides it per named
+	// logger, e.g. {"kafka": "debug"}.
+	Level       string
+	NamedLevels map[string]string
+	// Levels keeps the levels adjustable at runtime; a new one is created when nil.
+	Levels *Levels
+	// Sampling thins out repeated entries below error level; nil disables it.
+	Sampling *SamplingOptions
+	// Redactor masks sensitive fields of every entry, redact.Default() when nil.
+	Redactor *redact.Redactor
+	// Schema names the keys written to every sink, BracketedSchema when zero.
+	Schema Schema
+}
+
+// NewLogger builds the global Zap logger from the Log section and profile of
+// config.Current, or a development logger when no configuration is loaded.
 func NewLogger(customEnvPath ...string) *zap.Logger {
-	var logWriter zapcore.WriteSyncer
-	var encoderCfg zapcore.EncoderConfig
-	var encoder zapcore.Encoder
-
-	if config.IsProdEnv() {
-		encoderCfg = zap.NewProductionEncoderConfig()
-		encoderCfg.NameKey = "[SERVICE]"
-		encoderCfg.TimeKey = "[TIME]"
-		encoderCfg.LevelKey = "[LEVEL]"
-		encoderCfg.FunctionKey = "[CALLER]"
-		encoderCfg.CallerKey = "[LINE]"
-		encoderCfg.MessageKey = "[MESSAGE]"
-		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-		encoderCfg.EncodeLevel = zapcore.CapitalLevelEncoder
-		encoderCfg.EncodeCaller = zapcore.ShortCallerEncoder
-		encoderCfg.EncodeName = zapcore.FullNameEncoder
-		encoderCfg.EncodeDuration = zapcore.StringDurationEncoder
-		encoder = zapcore.NewJSONEncoder(encoderCfg)
-
-		_, callerDir, _, ok := runtime.Caller(0)
-		if !ok {
-			log.Fatal("Error getting caller directory")
+	opts := Options{}
+	if cfg := config.Current(); cfg != nil {
+		opts = Options{
+			Sinks:       cfg.Log.Sinks,
+			Production:  !cfg.Profile().ConsoleLogs,
+			Level:       cfg.Log.Level,
+			NamedLevels: cfg.Log.Levels,
+			File: FileSinkOptions{
+				Dir:            cfg.Log.Dir,
+				Filename:       cfg.Log.File,
+				MaxSize:        int64(cfg.Log.MaxSize),
+				MaxBackups:     cfg.Log.MaxBackups,
+				MaxAge:         cfg.Log.MaxAge,
+				Compress:       cfg.Log.Compress,
+				RotateInterval: cfg.Log.RotateInterval,
+			},
+		}
+	}
+	if customEnvPath != nil {
+		opts.File.Dir = customEnvPath[0]
+	}
+	opts.Levels = currentLevels
+	if cfg := config.Current(); cfg != nil {
+		sampling, err := samplingOptions(cfg.Log)
+		if err != nil {
+			log.Fatal(err)
 		}
+		opts.Sampling = sampling
 
-		var tmpLogDir string
-		if customEnvPath != nil {
-			tmpLogDir = customEnvPath[0]
-		} else {
-			tmpLogDir = filepath.Join(filepath.Dir(callerDir), "../..", "tmp/logs")
+		if opts.Schema, err = GetSchema(cfg.Log.Schema); err != nil {
+			log.Fatal(err)
 		}
+		if cfg.Log.Schema == constant.LogSchemaGCP {
+			opts.Schema = NewGCPSchema(cfg.Log.GCPProject)
+		}
+	}
+	if cfg := config.Current(); cfg != nil && len(cfg.Log.RedactKeys) > 0 {
+		redact.SetDefault(redact.New(redact.Options{
+			Keys:     append(append([]string{}, redact.DefaultKeys...), cfg.Log.RedactKeys...),
+			Patterns: redact.DefaultPatterns,
+		}))
+		opts.Redactor = redact.Default()
+	}
+
+	zl, closeFn, err := Build(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	closeMu.Lock()
+	prevClose := closeSink
+	Zap, closeSink = zl, closeFn
+	closeMu.Unlock()
+	InstallAdapters(Zap)
+	if prevClose != nil {
+		_ = prevClose()
+	}
+
+	return Zap
+}
+
+// Close flushes the global Zap logger and closes the files opened by NewLogger.
+func Close() error {
+	closeMu.Lock()
+	defer closeMu.Unlock()
+
+	if Zap != nil {
+		_ = Zap.Sync()
+	}
+	if closeSink == nil {
+		return nil
+	}
+	err := closeSink()
+	closeSink = nil
+
+	return err
+}
+
+// New builds a logger from explicit options without touching the global Zap. The
+// returned function syncs the logger and closes its file sinks.
+func New(opts Options) (*zap.Logger, func() error) {
+	zl, closeFn, err := Build(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return zl, func() error {
+		_ = zl.Sync()
+		return closeFn()
+	}
+}
 
-		if _, err := os.Stat(tmpLogDir); os.IsNotExist(err) {
-			_ = os.MkdirAll(tmpLogDir, os.ModePerm)
+// Build tees one core per sink and returns a function closing the opened files.
+func Build(opts Options) (*zap.Logger, func() error, error) {
+	sinks := opts.Sinks
+	if len(sinks) == 0 {
+		sinks = []string{constant.LogSinkConsole}
+		if opts.Production {
+			sinks = []string{constant.LogSinkFile}
 		}
+	}
+	if opts.File.Dir == "" {
+		opts.File.Dir = opts.LogDir
+	}
 
-		logFile, err := os.OpenFile(filepath.Clean(filepath.Join(tmpLogDir, "main.log")), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-		if err != nil {
-			log.Fatal("Error Open File main.log")
+	schema := opts.Schema
+	if schema.Name == "" {
+		schema = BracketedSchema
+	}
+
+	levels, err := buildLevels(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Every core enables all levels, levelCore filters by the levels above.
+	level := zapcore.DebugLevel
+	var cores []zapcore.Core
+	var files []*FileSink
+	closeFn := func() error {
+		var err error
+		for _, f := range files {
+			if cerr := f.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+
+	for _, sink := range sinks {
+		switch sink {
+		case constant.LogSinkConsole:
+			cores = append(cores, zapcore.NewCore(consoleEncoder(schema), zapcore.Lock(os.Stdout), level))
+		case constant.LogSinkStdout:
+			cores = append(cores, zapcore.NewCore(jsonEncoder(schema), zapcore.Lock(os.Stdout), level))
+		case constant.LogSinkStderr:
+			cores = append(cores, zapcore.NewCore(jsonEncoder(schema), zapcore.Lock(os.Stderr), zapcore.ErrorLevel))
+		case constant.LogSinkFile:
+			fs, err := NewFileSink(opts.File)
+			if err != nil {
+				_ = closeFn()
+				return nil, nil, err
+			}
+			files = append(files, fs)
+			cores = append(cores, zapcore.NewCore(jsonEncoder(schema), fs, level))
+		default:
+			_ = closeFn()
+			return nil, nil, errors.Errorf("logger: unknown sink %q", sink)
+		}
+	}
+
+	redactor := opts.Redactor
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+	// Each sink is wrapped on its own: a tee writes to every core without checking them.
+	// Fields are renamed last so redaction and sampling still see the loggerConstant keys.
+	for i := range cores {
+		cores[i] = redact.NewCore(newSchemaCore(cores[i], schema), redactor)
+	}
+	core := zapcore.NewTee(cores...)
+	if opts.Sampling != nil {
+		core = newSampleCore(core, *opts.Sampling)
+	}
+	core = &levelCore{Core: core, levels: levels}
+
+	return zap.New(core, zap.AddCaller()), closeFn, nil
+}
+
+func samplingOptions(logConf config.LogConfig) (*SamplingOptions, error) {
+	rule, err := ParseSamplingRule(logConf.Sampling)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := ParseSamplingRoutes(logConf.SampleRoutes)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Off && len(routes) == 0 {
+		return nil, nil
+	}
+
+	return &SamplingOptions{SamplingRule: rule, Tick: logConf.SampleTick, Routes: routes}, nil
+}
+
+func buildLevels(opts Options) (*Levels, error) {
+	levels := opts.Levels
+	if levels == nil {
+		levels = NewLevels(zapcore.DebugLevel)
+	}
+	if opts.Level == "" && opts.NamedLevels == nil {
+		return levels, nil
+	}
+
+	level := zapcore.DebugLevel
+	if opts.Level != "" {
+		var err error
+		if level, err = zapcore.ParseLevel(opts.Level); err != nil {
+			return nil, err
 		}
+	}
+	named, err := ParseLevels(opts.NamedLevels)
+	if err != nil {
+		return nil, err
+	}
+	levels.Apply(level, named)
+
+	return levels, nil
+}
+
+func jsonEncoder(schema Schema) zapcore.Encoder {
+	encoderCfg := schema.encoderConfig(zap.NewProductionEncoderConfig())
+	encoderCfg.EncodeLevel = schema.EncodeLevel
+	encoderCfg.EncodeCaller = zapcore.ShortCallerEncoder
+	encoderCfg.EncodeName = zapcore.FullNameEncoder
+
+	return zapcore.NewJSONEncoder(encoderCfg)
+}
+
+// consoleEncoder keeps the schema keys but colors the level for terminals.
+func consoleEncoder(schema Schema) zapcore.Encoder {
+	encoderCfg := schema.encoderConfig(zap.NewDevelopmentEncoderConfig())
+	encoderCfg.EncodeName = zapcore.FullNameEncoder
+	encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	encoderCfg.EncodeCaller = zapcore.FullCallerEncoder
+	encoderCfg.ConsoleSeparator = " | "
 
-		logWriter = zapcore.AddSync(logFile)
-	} else {
-		encoderCfg = zap.NewDevelopmentEncoderConfig()
-		encoderCfg.NameKey = "[SERVICE]"
-		encoderCfg.TimeKey = "[TIME]"
-		encoderCfg.LevelKey = "[LEVEL]"
-		encoderCfg.FunctionKey = "[CALLER]"
-		encoderCfg.CallerKey = "[LINE]"
-		encoderCfg.MessageKey = "[MESSAGE]"
-		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-		encoderCfg.EncodeName = zapcore.FullNameEncoder
-		encoderCfg.EncodeDuration = zapcore.StringDurationEncoder
-		encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		encoderCfg.EncodeCaller = zapcore.FullCallerEncoder
-		encoderCfg.ConsoleSeparator = " | "
-		encoder = zapcore.NewConsoleEncoder(encoderCfg)
-		logWriter = zapcore.AddSync(os.Stdout)
-	}
-
-	core := zapcore.NewCore(encoder, logWriter, zap.NewAtomicLevelAt(zapcore.DebugLevel))
-
-	zapNew := zap.New(core, zap.AddCaller())
-	Zap = zapNew
-	return zapNew
+	return zapcore.NewConsoleEncoder(encoderCfg)
 }
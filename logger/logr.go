@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logrSink maps logr verbosity onto zap levels: V(0) is info, any higher V is debug.
+type logrSink struct {
+	logger *zap.Logger
+}
+
+// NewLogr returns a logr.Logger writing through l, or the global Zap logger when nil.
+func NewLogr(l *zap.Logger) logr.Logger {
+	if l == nil {
+		l = FromContext(context.Background())
+	}
+
+	return logr.New(&logrSink{logger: l})
+}
+
+// LogrFromContext returns a logr.Logger carrying the fields of FromContext(ctx).
+func LogrFromContext(ctx context.Context) logr.Logger {
+	return NewLogr(FromContext(ctx))
+}
+
+func (ls *logrSink) Init(info logr.RuntimeInfo) {
+	ls.logger = ls.logger.WithOptions(zap.AddCallerSkip(info.CallDepth + 1))
+}
+
+func (ls *logrSink) Enabled(level int) bool {
+	return ls.logger.Core().Enabled(logrToZapLevel(level))
+}
+
+func (ls *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if ce := ls.logger.Check(logrToZapLevel(level), msg); ce != nil {
+		ce.Write(kvFields(keysAndValues)...)
+	}
+}
+
+func (ls *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	if ce := ls.logger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append(kvFields(keysAndValues), zap.Error(err))...)
+	}
+}
+
+func (ls *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logrSink{logger: ls.logger.With(kvFields(keysAndValues)...)}
+}
+
+func (ls *logrSink) WithName(name string) logr.LogSink {
+	return &logrSink{logger: ls.logger.Named(name)}
+}
+
+func logrToZapLevel(level int) zapcore.Level {
+	if level > 0 {
+		return zapcore.DebugLevel
+	}
+
+	return zapcore.InfoLevel
+}
+
+// kvFields converts alternating keys and values, as used by logr and CronLogger.
+func kvFields(keysAndValues []interface{}) []zap.Field {
+	fields := make([]zap.Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		if i+1 == len(keysAndValues) {
+			fields = append(fields, zap.Any("!BADKEY", keysAndValues[i]))
+			break
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+	}
+
+	return fields
+}
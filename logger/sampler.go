@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+)
+
+// SamplingRule logs the first Initial entries with the same message per tick, then
+// every Thereafter-th. Thereafter 0 drops the rest; Off disables sampling.
+type SamplingRule struct {
+	Initial    int
+	Thereafter int
+	Off        bool
+}
+
+type SamplingOptions struct {
+	SamplingRule
+	Tick time.Duration
+	// Routes overrides the rule for entries of a logger carrying a ROUTE or
+	// GRPC_METHOD field, e.g. {"/api/v1/health": {Initial: 1}}.
+	Routes map[string]SamplingRule
+}
+
+// ParseSamplingRule parses "initial:thereafter" or "off".
+func ParseSamplingRule(s string) (SamplingRule, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") {
+		return SamplingRule{Off: true}, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) == 2 {
+		initial, err1 := strconv.Atoi(parts[0])
+		thereafter, err2 := strconv.Atoi(parts[1])
+		if err1 == nil && err2 == nil && initial >= 0 && thereafter >= 0 {
+			return SamplingRule{Initial: initial, Thereafter: thereafter}, nil
+		}
+	}
+
+	return SamplingRule{}, errors.Errorf("logger: invalid sampling rule %q, expected initial:thereafter or off", s)
+}
+
+func ParseSamplingRoutes(routes map[string]string) (map[string]SamplingRule, error) {
+	rules := make(map[string]SamplingRule, len(routes))
+	for route, text := range routes {
+		rule, err := ParseSamplingRule(text)
+		if err != nil {
+			return nil, err
+		}
+		rules[route] = rule
+	}
+
+	return rules, nil
+}
+
+type sampler struct {
+	opts SamplingOptions
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func (s *sampler) allow(ent zapcore.Entry, route string) bool {
+	rule := s.opts.SamplingRule
+	if r, ok := s.opts.Routes[route]; ok && route != "" {
+		rule = r
+	}
+	if rule.Off {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Counters are dropped every tick so messages with dynamic text cannot grow the map.
+	if ent.Time.Sub(s.windowStart) >= s.opts.Tick || ent.Time.Before(s.windowStart) {
+		s.windowStart = ent.Time
+		s.counts = map[string]int{}
+	}
+
+	key := ent.Level.String() + "|" + route + "|" + ent.Message
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= rule.Initial {
+		return true
+	}
+
+	return rule.Thereafter > 0 && (n-rule.Initial)%rule.Thereafter == 0
+}
+
+// sampleCore samples entries below error level; errors are never dropped. The route
+// of an entry comes from the fields added with With, e.g. by the context logger.
+type sampleCore struct {
+	zapcore.Core
+	sampler *sampler
+	route   string
+}
+
+func newSampleCore(core zapcore.Core, opts SamplingOptions) zapcore.Core {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+
+	return &sampleCore{Core: core, sampler: &sampler{opts: opts, counts: map[string]int{}}}
+}
+
+func (sc *sampleCore) With(fields []zapcore.Field) zapcore.Core {
+	route := sc.route
+	for _, f := range fields {
+		if f.Type != zapcore.StringType {
+			continue
+		}
+		switch f.Key {
+		case loggerConstant.ROUTE:
+			route = f.String
+		case loggerConstant.GRPC_METHOD:
+			if route == "" {
+				route = f.String
+			}
+		}
+	}
+
+	return &sampleCore{Core: sc.Core.With(fields), sampler: sc.sampler, route: route}
+}
+
+func (sc *sampleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < zapcore.ErrorLevel && !sc.sampler.allow(ent, sc.route) {
+		return ce
+	}
+
+	return sc.Core.Check(ent, ce)
+}
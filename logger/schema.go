@@ -0,0 +1,309 @@
+package logger
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/diki-haryadi/ztools/constant"
+	lc "github.com/diki-haryadi/ztools/constant/logger"
+)
+
+// Schema names the entry keys and renames the loggerConstant field keys written by
+// ztools, so the echo, gRPC, Kafka and error handler logs match the log pipeline.
+type Schema struct {
+	Name          string
+	TimeKey       string
+	LevelKey      string
+	NameKey       string
+	CallerKey     string
+	FunctionKey   string
+	MessageKey    string
+	StacktraceKey string
+	EncodeLevel   zapcore.LevelEncoder
+	EncodeTime    zapcore.TimeEncoder
+	// EncodeDuration writes durations, as strings such as "1.5s" when nil.
+	EncodeDuration zapcore.DurationEncoder
+	// TracePrefix is prepended to TRACE_ID values, see NewGCPSchema.
+	TracePrefix string
+	// Fields maps a loggerConstant key to its name in the schema; other keys are kept.
+	Fields map[string]string
+	// Static fields are added to every entry, e.g. ecs.version.
+	Static []zap.Field
+}
+
+// BracketedSchema is the historical ztools layout, e.g. [LEVEL] and [MESSAGE].
+var BracketedSchema = Schema{
+	Name:        constant.LogSchemaBracketed,
+	TimeKey:     "[TIME]",
+	LevelKey:    "[LEVEL]",
+	NameKey:     "[SERVICE]",
+	CallerKey:   "[LINE]",
+	FunctionKey: "[CALLER]",
+	MessageKey:  "[MESSAGE]",
+	EncodeLevel: zapcore.CapitalLevelEncoder,
+	EncodeTime:  zapcore.ISO8601TimeEncoder,
+	Fields: map[string]string{
+		lc.GRPC_METHOD: lc.METHOD,
+	},
+}
+
+// ECSSchema follows the Elastic Common Schema.
+var ECSSchema = Schema{
+	Name:          constant.LogSchemaECS,
+	TimeKey:       "@timestamp",
+	LevelKey:      "log.level",
+	NameKey:       "log.logger",
+	CallerKey:     "log.origin.file.name",
+	FunctionKey:   "log.origin.function",
+	MessageKey:    "message",
+	StacktraceKey: "error.stack_trace",
+	EncodeLevel:   zapcore.LowercaseLevelEncoder,
+	EncodeTime:    zapcore.RFC3339NanoTimeEncoder,
+	// event.duration is in nanoseconds.
+	EncodeDuration: zapcore.NanosDurationEncoder,
+	Fields: map[string]string{
+		lc.TYPE:        "event.module",
+		lc.METHOD:      "http.request.method",
+		lc.GRPC_METHOD: "rpc.method",
+		lc.REQUEST_ID:  "http.request.id",
+		lc.URI:         "url.original",
+		lc.ROUTE:       "http.route",
+		lc.STATUS:      "status",
+		lc.STATUS_CODE: "http.response.status_code",
+		lc.LATENCY:     "event.duration",
+		lc.TIME:        "event.created",
+		lc.REQUEST:     "request",
+		lc.REPLY:       "response",
+		lc.METADATA:    "metadata",
+		lc.NAME:        "name",
+		lc.MSG:         "error.description",
+		lc.ERR:         "error.message",
+		lc.TITILE:      "error.type",
+		lc.CODE:        "error.code",
+		lc.DETAILS:     "error.details",
+		lc.STACK_TRACE: "error.stack_trace",
+		lc.TRACE_ID:    "trace.id",
+		lc.SPAN_ID:     "span.id",
+		lc.TOPIC:       "kafka.topic",
+		lc.PARTITION:   "kafka.partition",
+		lc.OFFSET:      "kafka.offset",
+	},
+	Static: []zap.Field{zap.String("ecs.version", "8.11.0")},
+}
+
+// OTelSchema follows the OpenTelemetry log data model and semantic conventions.
+var OTelSchema = Schema{
+	Name:          constant.LogSchemaOTel,
+	TimeKey:       "timestamp",
+	LevelKey:      "severity_text",
+	NameKey:       "scope.name",
+	CallerKey:     "code.filepath",
+	FunctionKey:   "code.function",
+	MessageKey:    "body",
+	StacktraceKey: "exception.stacktrace",
+	EncodeLevel:   zapcore.CapitalLevelEncoder,
+	EncodeTime:    zapcore.RFC3339NanoTimeEncoder,
+	Fields: map[string]string{
+		lc.TYPE:        "ztools.type",
+		lc.METHOD:      "http.request.method",
+		lc.GRPC_METHOD: "rpc.method",
+		lc.REQUEST_ID:  "http.request.id",
+		lc.URI:         "url.path",
+		lc.ROUTE:       "http.route",
+		lc.STATUS:      "status",
+		lc.STATUS_CODE: "http.response.status_code",
+		lc.LATENCY:     "duration",
+		lc.TIME:        "event.time",
+		lc.REQUEST:     "request",
+		lc.REPLY:       "response",
+		lc.METADATA:    "metadata",
+		lc.NAME:        "name",
+		lc.MSG:         "error.description",
+		lc.ERR:         "exception.message",
+		lc.TITILE:      "error.type",
+		lc.CODE:        "error.code",
+		lc.DETAILS:     "error.details",
+		lc.STACK_TRACE: "exception.stacktrace",
+		lc.TRACE_ID:    "trace_id",
+		lc.SPAN_ID:     "span_id",
+		lc.TOPIC:       "messaging.destination.name",
+		lc.PARTITION:   "messaging.kafka.destination.partition",
+		lc.OFFSET:      "messaging.kafka.message.offset",
+	},
+}
+
+// GCPSchema follows the Cloud Logging structured logging special fields. Cloud
+// Logging only links the trace of an entry written with NewGCPSchema.
+var GCPSchema = Schema{
+	Name:          constant.LogSchemaGCP,
+	TimeKey:       "time",
+	LevelKey:      "severity",
+	NameKey:       "logger",
+	CallerKey:     "caller",
+	FunctionKey:   "function",
+	MessageKey:    "message",
+	StacktraceKey: "stack_trace",
+	EncodeLevel:   gcpLevelEncoder,
+	EncodeTime:    zapcore.RFC3339NanoTimeEncoder,
+	Fields: map[string]string{
+		lc.TYPE:        "type",
+		lc.METHOD:      "requestMethod",
+		lc.GRPC_METHOD: "grpcMethod",
+		lc.REQUEST_ID:  "requestId",
+		lc.URI:         "requestUrl",
+		lc.ROUTE:       "route",
+		lc.STATUS:      "status",
+		lc.STATUS_CODE: "statusCode",
+		lc.LATENCY:     "latency",
+		lc.TIME:        "eventTime",
+		lc.REQUEST:     "request",
+		lc.REPLY:       "response",
+		lc.METADATA:    "metadata",
+		lc.NAME:        "name",
+		lc.MSG:         "errorMessage",
+		lc.ERR:         "error",
+		lc.TITILE:      "errorType",
+		lc.CODE:        "errorCode",
+		lc.DETAILS:     "errorDetails",
+		lc.STACK_TRACE: "stack_trace",
+		lc.TRACE_ID:    "traceId",
+		lc.SPAN_ID:     "logging.googleapis.com/spanId",
+		lc.TOPIC:       "topic",
+		lc.PARTITION:   "partition",
+		lc.OFFSET:      "offset",
+	},
+}
+
+// NewGCPSchema writes TRACE_ID as logging.googleapis.com/trace in the
+// projects/<projectID>/traces/<id> form expected by Cloud Logging.
+func NewGCPSchema(projectID string) Schema {
+	s := GCPSchema
+	s.Fields = make(map[string]string, len(GCPSchema.Fields))
+	for k, v := range GCPSchema.Fields {
+		s.Fields[k] = v
+	}
+	s.Fields[lc.TRACE_ID] = "logging.googleapis.com/trace"
+	s.TracePrefix = "projects/" + projectID + "/traces/"
+
+	return s
+}
+
+var schemas = map[string]Schema{
+	BracketedSchema.Name: BracketedSchema,
+	ECSSchema.Name:       ECSSchema,
+	OTelSchema.Name:      OTelSchema,
+	GCPSchema.Name:       GCPSchema,
+}
+
+// GetSchema returns the built-in schema selected by LOG_SCHEMA, bracketed when empty.
+func GetSchema(name string) (Schema, error) {
+	if name == "" {
+		return BracketedSchema, nil
+	}
+	s, ok := schemas[name]
+	if !ok {
+		return Schema{}, errors.Errorf("logger: unknown schema %q", name)
+	}
+
+	return s, nil
+}
+
+// Key returns the name of a loggerConstant key in the schema.
+func (s Schema) Key(key string) string {
+	if renamed, ok := s.Fields[key]; ok {
+		return renamed
+	}
+
+	return key
+}
+
+func (s Schema) encoderConfig(base zapcore.EncoderConfig) zapcore.EncoderConfig {
+	base.TimeKey = s.TimeKey
+	base.LevelKey = s.LevelKey
+	base.NameKey = s.NameKey
+	base.CallerKey = s.CallerKey
+	base.FunctionKey = s.FunctionKey
+	base.MessageKey = s.MessageKey
+	if s.StacktraceKey != "" {
+		base.StacktraceKey = s.StacktraceKey
+	}
+	if s.EncodeTime != nil {
+		base.EncodeTime = s.EncodeTime
+	}
+	base.EncodeDuration = zapcore.StringDurationEncoder
+	if s.EncodeDuration != nil {
+		base.EncodeDuration = s.EncodeDuration
+	}
+
+	return base
+}
+
+func gcpLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// schemaCore renames the fields of every entry written to a sink.
+type schemaCore struct {
+	zapcore.Core
+	schema Schema
+}
+
+func newSchemaCore(core zapcore.Core, schema Schema) zapcore.Core {
+	sc := &schemaCore{Core: core, schema: schema}
+	if len(schema.Static) > 0 {
+		sc.Core = core.With(schema.Static)
+	}
+
+	return sc
+}
+
+func (sc *schemaCore) With(fields []zapcore.Field) zapcore.Core {
+	return &schemaCore{Core: sc.Core.With(sc.rename(fields)), schema: sc.schema}
+}
+
+func (sc *schemaCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if sc.Enabled(ent.Level) {
+		return ce.AddCore(ent, sc)
+	}
+
+	return ce
+}
+
+func (sc *schemaCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return sc.Core.Write(ent, sc.rename(fields))
+}
+
+func (sc *schemaCore) rename(fields []zapcore.Field) []zapcore.Field {
+	if len(sc.schema.Fields) == 0 {
+		return fields
+	}
+
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		if f.Key == lc.TRACE_ID && f.Type == zapcore.StringType && sc.schema.TracePrefix != "" {
+			f.String = sc.schema.TracePrefix + f.String
+		}
+		f.Key = sc.schema.Key(f.Key)
+		out[i] = f
+	}
+
+	return out
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	lc "github.com/diki-haryadi/ztools/constant/logger"
+)
+
+func TestSchemaKeys(t *testing.T) {
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+
+	tests := []struct {
+		schema Schema
+		want   map[string]interface{}
+	}{
+		{
+			schema: ECSSchema,
+			want: map[string]interface{}{
+				"message":                   "request failed",
+				"http.response.status_code": float64(503),
+				"status":                    "Service Unavailable",
+				"event.duration":            float64(1500 * time.Millisecond),
+				"error.message":             "dial tcp: timeout",
+				"error.description":         "service unavailable",
+				"trace.id":                  traceID,
+				"ecs.version":               "8.11.0",
+			},
+		},
+		{
+			schema: OTelSchema,
+			want: map[string]interface{}{
+				"body":                      "request failed",
+				"http.response.status_code": float64(503),
+				"status":                    "Service Unavailable",
+				"duration":                  "1.5s",
+				"exception.message":         "dial tcp: timeout",
+				"error.description":         "service unavailable",
+				"trace_id":                  traceID,
+			},
+		},
+		{
+			schema: NewGCPSchema("my-project"),
+			want: map[string]interface{}{
+				"message":                      "request failed",
+				"severity":                     "ERROR",
+				"statusCode":                   float64(503),
+				"latency":                      "1.5s",
+				"error":                        "dial tcp: timeout",
+				"errorMessage":                 "service unavailable",
+				"logging.googleapis.com/trace": "projects/my-project/traces/" + traceID,
+			},
+		},
+		{
+			schema: GCPSchema,
+			want: map[string]interface{}{
+				"traceId": traceID,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			core := zapcore.NewCore(jsonEncoder(tt.schema), zapcore.AddSync(&buf), zapcore.DebugLevel)
+			zap.New(newSchemaCore(core, tt.schema)).Error(
+				"request failed",
+				zap.String(lc.STATUS, "Service Unavailable"),
+				zap.Int(lc.STATUS_CODE, 503),
+				zap.Duration(lc.LATENCY, 1500*time.Millisecond),
+				zap.String(lc.ERR, "dial tcp: timeout"),
+				zap.String(lc.MSG, "service unavailable"),
+				zap.String(lc.TRACE_ID, traceID),
+			)
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("decode %q: %v", buf.String(), err)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %#v, want %#v", key, got[key], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/diki-haryadi/ztools/constant"
+)
+
+type FileSinkOptions struct {
+	Dir      string
+	Filename string
+	// MaxSize in bytes triggers a rotation; it is rounded up to whole megabytes.
+	MaxSize    int64
+	MaxBackups int
+	// MaxAge removes rotated files older than this, rounded up to whole days.
+	MaxAge   time.Duration
+	Compress bool
+	// RotateInterval rotates on a fixed schedule on top of MaxSize; zero disables it.
+	RotateInterval time.Duration
+}
+
+// FileSink is a size and time based rotating log file.
+type FileSink struct {
+	*lumberjack.Logger
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewFileSink(opts FileSinkOptions) (*FileSink, error) {
+	dir := opts.Dir
+	if dir == "" {
+		dir = constant.LogDir
+	}
+	name := opts.Filename
+	if name == "" {
+		name = constant.LogFile
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	fs := &FileSink{
+		Logger: &lumberjack.Logger{
+			Filename:   filepath.Clean(filepath.Join(dir, name)),
+			MaxSize:    int(ceilDiv(opts.MaxSize, 1<<20)),
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     int(ceilDiv(int64(opts.MaxAge), int64(24*time.Hour))),
+			Compress:   opts.Compress,
+			LocalTime:  true,
+		},
+		stop: make(chan struct{}),
+	}
+
+	if opts.RotateInterval > 0 {
+		go fs.rotateEvery(opts.RotateInterval)
+	}
+
+	return fs, nil
+}
+
+func (fs *FileSink) rotateEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fs.stop:
+			return
+		case <-ticker.C:
+			_ = fs.Rotate()
+		}
+	}
+}
+
+func (fs *FileSink) Sync() error {
+	return nil
+}
+
+// Close stops the rotation schedule and closes the current file.
+func (fs *FileSink) Close() error {
+	fs.stopOnce.Do(func() {
+		close(fs.stop)
+	})
+
+	return fs.Logger.Close()
+}
+
+var _ zapcore.WriteSyncer = (*FileSink)(nil)
+
+func ceilDiv(a int64, b int64) int64 {
+	if a <= 0 {
+		return 0
+	}
+
+	return (a + b - 1) / b
+}
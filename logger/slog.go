@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a slog.Handler writing through zap. Records are written with the
+// logger stored in their context when there is one, so they carry its fields.
+type SlogHandler struct {
+	logger *zap.Logger
+	fields []zap.Field
+}
+
+// NewSlogHandler writes through l, or through the global Zap logger when l is nil.
+func NewSlogHandler(l *zap.Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+func (h *SlogHandler) base(ctx context.Context) *zap.Logger {
+	fallback := h.logger
+	if fallback == nil {
+		fallback = Zap
+	}
+
+	return fromContextOr(ctx, fallback)
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base(ctx).Core().Enabled(slogToZapLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ce := h.base(ctx).Check(slogToZapLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(r.PC, frame.File, frame.Line, true)
+		ce.Caller.Function = frame.Function
+	}
+
+	fields := make([]zap.Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+		return true
+	})
+	ce.Write(fields...)
+
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]zap.Field{}, h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+
+	return &SlogHandler{logger: h.logger, fields: fields}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{logger: h.logger, fields: append(append([]zap.Field{}, h.fields...), zap.Namespace(name))}
+}
+
+func appendAttr(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		var group []zap.Field
+		for _, ga := range a.Value.Group() {
+			group = appendAttr(group, ga)
+		}
+		if a.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, zap.Dict(a.Key, group...))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+func slogToZapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
@@ -7,17 +7,18 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
-
-	"github.com/diki-haryadi/ztools/config"
 )
 
 type Config struct {
-	Host    string
-	Port    string
-	User    string
-	Pass    string
-	DBName  string
-	SslMode string
+	Host            string
+	Port            string
+	User            string
+	Pass            string
+	DBName          string
+	SslMode         string
+	MaxConn         int
+	MaxIdleConn     int
+	MaxLifeTimeConn time.Duration
 }
 
 type Postgres struct {
@@ -29,6 +30,13 @@ func (db *Postgres) Close() {
 	_ = db.SqlxDB.Close()
 }
 
+// SetPool applies new pool limits to the open connection, e.g. from a config.Watcher subscription.
+func (db *Postgres) SetPool(maxConn int, maxIdleConn int, maxLifeTimeConn time.Duration) {
+	db.SqlxDB.SetMaxOpenConns(maxConn)
+	db.SqlxDB.SetMaxIdleConns(maxIdleConn)
+	db.SqlxDB.SetConnMaxLifetime(maxLifeTimeConn)
+}
+
 func NewConnection(ctx context.Context, conf *Config) (*Postgres, error) {
 	connString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		conf.Host,
@@ -44,9 +52,11 @@ func NewConnection(ctx context.Context, conf *Config) (*Postgres, error) {
 		return nil, err
 	}
 
-	db.SetMaxOpenConns(config.BaseConfig.Postgres.MaxConn)                           // the defaultLogger is 0 (unlimited)
-	db.SetMaxIdleConns(config.BaseConfig.Postgres.MaxIdleConn)                       // defaultMaxIdleConn = 2
-	db.SetConnMaxLifetime(time.Duration(config.BaseConfig.Postgres.MaxLifeTimeConn)) // 0, connections are reused forever
+	db.SetMaxOpenConns(conf.MaxConn) // the defaultLogger is 0 (unlimited)
+	if conf.MaxIdleConn > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConn) // defaultMaxIdleConn = 2
+	}
+	db.SetConnMaxLifetime(conf.MaxLifeTimeConn) // 0, connections are reused forever
 
 	if err := db.Ping(); err != nil {
 		fmt.Println("can not ping postgres")
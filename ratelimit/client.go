@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KeyValidator reports whether apiKey belongs to a known client. Only validated keys
+// get a limit of their own, otherwise a client could send a new key per request.
+type KeyValidator func(ctx context.Context, apiKey string) bool
+
+// ParseTrustedProxies parses CIDRs, e.g. 10.0.0.0/8, or single IPs of the proxies
+// whose X-Forwarded-For header is trusted.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, errors.Errorf("ratelimit: invalid trusted proxy %q", p)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ratelimit: invalid trusted proxy %q", p)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// ClientIP returns the address of the client of a request received from remoteIP.
+// X-Forwarded-For is only read when remoteIP is a trusted proxy, and then from the
+// right, up to the first address that is not a trusted proxy.
+func ClientIP(remoteIP string, forwardedFor []string, trusted []*net.IPNet) string {
+	if !isTrusted(remoteIP, trusted) {
+		return remoteIP
+	}
+
+	client := remoteIP
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		hops := strings.Split(forwardedFor[i], ",")
+		for j := len(hops) - 1; j >= 0; j-- {
+			hop := strings.TrimSpace(hops[j])
+			if net.ParseIP(hop) == nil {
+				return client
+			}
+			client = hop
+			if !isTrusted(hop, trusted) {
+				return client
+			}
+		}
+	}
+
+	return client
+}
+
+func isTrusted(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "cidr", in: []string{"10.0.0.0/8"}, want: []string{"10.0.0.0/8"}},
+		{name: "cidr with host bits", in: []string{"192.168.1.7/24"}, want: []string{"192.168.1.0/24"}},
+		{name: "ipv4", in: []string{"10.1.2.3"}, want: []string{"10.1.2.3/32"}},
+		{name: "ipv6", in: []string{"::1"}, want: []string{"::1/128"}},
+		{name: "blank and spaces", in: []string{" 10.0.0.0/8 ", "", "fd00::/8"}, want: []string{"10.0.0.0/8", "fd00::/8"}},
+		{name: "none", in: nil, want: []string{}},
+		{name: "invalid ip", in: []string{"10.0.0.256"}, wantErr: true},
+		{name: "invalid cidr", in: []string{"10.0.0.0/33"}, wantErr: true},
+		{name: "hostname", in: []string{"proxy.local"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nets, err := ParseTrustedProxies(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrustedProxies(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(nets) != len(tt.want) {
+				t.Fatalf("ParseTrustedProxies(%v) = %v, want %v", tt.in, nets, tt.want)
+			}
+			for i, n := range nets {
+				if n.String() != tt.want[i] {
+					t.Errorf("ParseTrustedProxies(%v)[%d] = %s, want %s", tt.in, i, n, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := mustParseTrustedProxies(t, "10.0.0.0/8", "fd00::/8")
+
+	tests := []struct {
+		name         string
+		remoteIP     string
+		forwardedFor []string
+		// untrusted clears the trusted proxies.
+		untrusted bool
+		want      string
+	}{
+		{name: "direct", remoteIP: "203.0.113.7", want: "203.0.113.7"},
+		{name: "untrusted remote ignores header", remoteIP: "203.0.113.7", forwardedFor: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted remote without header", remoteIP: "10.0.0.1", want: "10.0.0.1"},
+		{name: "trusted remote", remoteIP: "10.0.0.1", forwardedFor: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed left hops", remoteIP: "10.0.0.1", forwardedFor: []string{"1.1.1.1, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of proxies", remoteIP: "10.0.0.1", forwardedFor: []string{"198.51.100.1, 10.0.0.3, 10.0.0.2"}, want: "198.51.100.1"},
+		{name: "several headers", remoteIP: "10.0.0.1", forwardedFor: []string{"1.1.1.1, 198.51.100.1", "10.0.0.2"}, want: "198.51.100.1"},
+		{name: "only proxies", remoteIP: "10.0.0.1", forwardedFor: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{name: "invalid hop", remoteIP: "10.0.0.1", forwardedFor: []string{"198.51.100.1, unknown"}, want: "10.0.0.1"},
+		{name: "invalid hop after proxy", remoteIP: "10.0.0.1", forwardedFor: []string{"garbage, 10.0.0.2"}, want: "10.0.0.2"},
+		{name: "ipv6", remoteIP: "fd00::1", forwardedFor: []string{"2001:db8::1"}, want: "2001:db8::1"},
+		{name: "no trusted proxies", remoteIP: "10.0.0.1", forwardedFor: []string{"198.51.100.1"}, untrusted: true, want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies := trusted
+			if tt.untrusted {
+				proxies = nil
+			}
+			if got := ClientIP(tt.remoteIP, tt.forwardedFor, proxies); got != tt.want {
+				t.Errorf("ClientIP(%q, %q) = %q, want %q", tt.remoteIP, tt.forwardedFor, got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParseTrustedProxies(t *testing.T, proxies ...string) []*net.IPNet {
+	t.Helper()
+	nets, err := ParseTrustedProxies(proxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return nets
+}
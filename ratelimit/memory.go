@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/diki-haryadi/ztools/constant"
+)
+
+// sweepInterval is how often the idle keys of the memory limiter are dropped.
+const sweepInterval = time.Minute
+
+// memoryLimiter runs the same algorithms as the scripts within one replica.
+type memoryLimiter struct {
+	mu        sync.Mutex
+	algorithm string
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	// hits of the sliding window, oldest first.
+	hits []time.Time
+	// tokens of the bucket, as of last.
+	tokens    float64
+	last      time.Time
+	expiresAt time.Time
+}
+
+func newMemoryLimiter(algorithm string) *memoryLimiter {
+	return &memoryLimiter{
+		algorithm: algorithm,
+		entries:   map[string]*memoryEntry{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *memoryLimiter) allow(key string, limit Limit, now time.Time) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &memoryEntry{}
+		m.entries[key] = e
+	}
+
+	if m.algorithm == constant.RateLimitTokenBucket {
+		return e.takeToken(limit, now)
+	}
+	return e.hit(limit, now)
+}
+
+func (e *memoryEntry) hit(limit Limit, now time.Time) Result {
+	cut := now.Add(-limit.Period)
+	i := 0
+	for i < len(e.hits) && !e.hits[i].After(cut) {
+		i++
+	}
+	e.hits = e.hits[i:]
+	e.expiresAt = now.Add(limit.Period)
+
+	res := Result{Limit: limit.Rate}
+	if len(e.hits) < limit.Rate {
+		e.hits = append(e.hits, now)
+		res.Allowed = true
+		res.Remaining = limit.Rate - len(e.hits)
+		return res
+	}
+
+	res.RetryAfter = e.hits[0].Add(limit.Period).Sub(now)
+	return res
+}
+
+func (e *memoryEntry) takeToken(limit Limit, now time.Time) Result {
+	capacity := float64(limit.capacity(constant.RateLimitTokenBucket))
+	interval := float64(limit.Period) / float64(limit.Rate)
+
+	if e.last.IsZero() {
+		e.tokens = capacity
+	} else {
+		e.tokens = math.Min(capacity, e.tokens+float64(now.Sub(e.last))/interval)
+	}
+	e.last = now
+	e.expiresAt = now.Add(time.Duration(capacity * interval))
+
+	res := Result{Limit: int(capacity)}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - e.tokens) * interval))
+	}
+	res.Remaining = int(e.tokens)
+
+	return res
+}
+
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, e := range m.entries {
+		if now.After(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/diki-haryadi/ztools/constant"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	type step struct {
+		at         time.Duration
+		key        string
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		limit     Limit
+		steps     []step
+	}{
+		{
+			name:      "sliding window",
+			algorithm: constant.RateLimitSlidingWindow,
+			limit:     Limit{Rate: 3, Period: time.Second},
+			steps: []step{
+				{at: 0, allowed: true, remaining: 2},
+				{at: 100 * time.Millisecond, allowed: true, remaining: 1},
+				{at: 200 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 300 * time.Millisecond, retryAfter: 700 * time.Millisecond},
+				{at: 300 * time.Millisecond, key: "other", allowed: true, remaining: 2},
+				{at: 999 * time.Millisecond, retryAfter: time.Millisecond},
+				{at: time.Second, allowed: true, remaining: 0},
+				{at: 1100 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 1150 * time.Millisecond, retryAfter: 50 * time.Millisecond},
+			},
+		},
+		{
+			name:      "token bucket",
+			algorithm: constant.RateLimitTokenBucket,
+			limit:     Limit{Rate: 2, Period: time.Second, Burst: 4},
+			steps: []step{
+				{at: 0, allowed: true, remaining: 3},
+				{at: 0, allowed: true, remaining: 2},
+				{at: 0, allowed: true, remaining: 1},
+				{at: 0, allowed: true, remaining: 0},
+				{at: 0, retryAfter: 500 * time.Millisecond},
+				{at: 0, key: "other", allowed: true, remaining: 3},
+				{at: 250 * time.Millisecond, retryAfter: 250 * time.Millisecond},
+				{at: 500 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 3 * time.Second, allowed: true, remaining: 3},
+			},
+		},
+		{
+			name:      "token bucket without burst",
+			algorithm: constant.RateLimitTokenBucket,
+			limit:     Limit{Rate: 1, Period: time.Second},
+			steps: []step{
+				{at: 0, allowed: true, remaining: 0},
+				{at: 0, retryAfter: time.Second},
+				{at: time.Second, allowed: true, remaining: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMemoryLimiter(tt.algorithm)
+			start := time.Now()
+			for i, s := range tt.steps {
+				key := s.key
+				if key == "" {
+					key = "client"
+				}
+				res := m.allow(key, tt.limit, start.Add(s.at))
+				if res.Allowed != s.allowed || res.Remaining != s.remaining || res.RetryAfter != s.retryAfter {
+					t.Errorf("step %d at %v: allow(%q) = %+v, want allowed %v, remaining %d, retry after %v",
+						i, s.at, key, res, s.allowed, s.remaining, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterSweepsIdleKeys(t *testing.T) {
+	m := newMemoryLimiter(constant.RateLimitSlidingWindow)
+	limit := Limit{Rate: 1, Period: time.Second}
+	start := time.Now()
+
+	m.allow("idle", limit, start)
+	m.allow("active", limit, start.Add(sweepInterval))
+	if _, ok := m.entries["idle"]; ok {
+		t.Error("idle key kept after the sweep interval")
+	}
+	if _, ok := m.entries["active"]; !ok {
+		t.Error("active key dropped by the sweep")
+	}
+}
+
+func TestAllowInvalidLimit(t *testing.T) {
+	l := New(nil, Options{})
+	for _, limit := range []Limit{{Rate: 0, Period: time.Second}, {Rate: 1}, {Rate: -1, Period: -time.Second}} {
+		if _, err := l.Allow(context.Background(), "client", limit); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Allow(%+v) error = %v, want ErrInvalidLimit", limit, err)
+		}
+	}
+}
+
+func TestAllowFallsBackToMemory(t *testing.T) {
+	// Nothing listens on the discard port, so every Redis call fails.
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:9", MaxRetries: -1})
+	defer client.Close()
+	l := New(client, Options{RedisCooldown: time.Minute})
+	limit := Limit{Rate: 2, Period: time.Minute}
+
+	for i, want := range []bool{true, true, false} {
+		res, err := l.Allow(context.Background(), "client", limit)
+		if err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		if res.Allowed != want {
+			t.Errorf("request %d: Allow() = %v, want %v", i, res.Allowed, want)
+		}
+	}
+	if l.redisDownUntil.Load() <= time.Now().UnixNano() {
+		t.Error("Redis is not skipped after a failure")
+	}
+}
@@ -0,0 +1,188 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/diki-haryadi/ztools/constant"
+	loggerConstant "github.com/diki-haryadi/ztools/constant/logger"
+	"github.com/diki-haryadi/ztools/logger"
+)
+
+// loggerName lets LOG_LEVELS=ratelimit=debug change the level of the limiter logs only.
+const loggerName = "ratelimit"
+
+const (
+	defaultRedisTimeout  = 100 * time.Millisecond
+	defaultRedisCooldown = 5 * time.Second
+)
+
+// Headers of the limited responses; gRPC sends them lower-cased as metadata.
+const (
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderRetryAfter = "Retry-After"
+)
+
+var ErrInvalidLimit = errors.New("ratelimit: rate and period must be positive")
+
+// Limit allows Rate requests per Period. The token bucket refills at the same pace and
+// holds up to Burst tokens, Rate when zero.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+func (l Limit) capacity(algorithm string) int {
+	if algorithm == constant.RateLimitTokenBucket && l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Rate
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a rejected caller has to wait.
+	RetryAfter time.Duration
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as the Retry-After header wants.
+func (r Result) RetryAfterSeconds() int {
+	return int(math.Ceil(r.RetryAfter.Seconds()))
+}
+
+type Options struct {
+	// Algorithm is sliding_window, the default, or token_bucket.
+	Algorithm string
+	// Prefix namespaces the Redis keys, usually App.AppName + ":".
+	Prefix string
+	// RedisTimeout bounds a Redis call, retries included, 100ms by default, so a slow
+	// Redis does not stall the requests it limits.
+	RedisTimeout time.Duration
+	// RedisCooldown is how long the limiter stays in memory after Redis failed, 5s by
+	// default.
+	RedisCooldown time.Duration
+}
+
+type Limiter struct {
+	client        redis.UniversalClient
+	script        *redis.Script
+	memory        *memoryLimiter
+	algorithm     string
+	prefix        string
+	redisTimeout  time.Duration
+	redisCooldown time.Duration
+	// redisDownUntil is the UnixNano time until which Redis is skipped.
+	redisDownUntil atomic.Int64
+}
+
+// New limits in Redis, shared by every replica, when client is set and in memory
+// otherwise. When Redis fails the limiter falls back to the memory of the replica for
+// RedisCooldown, so an outage loosens the limits instead of rejecting or allowing
+// every request.
+func New(client redis.UniversalClient, opts Options) *Limiter {
+	algorithm := opts.Algorithm
+	if algorithm == "" {
+		algorithm = constant.RateLimitSlidingWindow
+	}
+
+	redisTimeout := opts.RedisTimeout
+	if redisTimeout <= 0 {
+		redisTimeout = defaultRedisTimeout
+	}
+	redisCooldown := opts.RedisCooldown
+	if redisCooldown <= 0 {
+		redisCooldown = defaultRedisCooldown
+	}
+
+	l := &Limiter{
+		client:        client,
+		memory:        newMemoryLimiter(algorithm),
+		algorithm:     algorithm,
+		prefix:        opts.Prefix,
+		redisTimeout:  redisTimeout,
+		redisCooldown: redisCooldown,
+	}
+	switch algorithm {
+	case constant.RateLimitTokenBucket:
+		l.script = tokenBucketScript
+	default:
+		l.script = slidingWindowScript
+	}
+
+	return l
+}
+
+// Allow counts a request of key against limit. Keys are shared by every limit, so
+// scope them, e.g. "login:ip:10.0.0.1", when one client has several limits. Keys end
+// up in Redis and in logs: pass secrets such as API keys through HashKey.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return Result{}, ErrInvalidLimit
+	}
+
+	now := time.Now()
+	if l.client != nil && now.UnixNano() >= l.redisDownUntil.Load() {
+		res, err := l.allowRedis(ctx, key, limit)
+		if err == nil {
+			return res, nil
+		}
+		// A caller going away is not a Redis failure. Otherwise only the request that
+		// opens the cooldown logs, not every request during it.
+		downUntil := l.redisDownUntil.Load()
+		if ctx.Err() == nil && now.UnixNano() >= downUntil &&
+			l.redisDownUntil.CompareAndSwap(downUntil, now.Add(l.redisCooldown).UnixNano()) {
+			logger.FromContext(ctx).Named(loggerName).Warn("redis rate limit failed, limiting in memory",
+				zap.String(loggerConstant.NAME, key),
+				zap.String(loggerConstant.ERR, err.Error()),
+			)
+		}
+	}
+
+	return l.memory.allow(key, limit, now), nil
+}
+
+// HashKey returns a digest of secret, e.g. an API key, to limit it without storing
+// or logging it.
+func HashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (l *Limiter) allowRedis(ctx context.Context, key string, limit Limit) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.redisTimeout)
+	defer cancel()
+
+	capacity := limit.capacity(l.algorithm)
+	args := []interface{}{capacity, limit.Rate, limit.Period.Microseconds()}
+	if l.algorithm != constant.RateLimitTokenBucket {
+		args = append(args, newMember())
+	}
+
+	values, err := l.script.Run(ctx, l.client, []string{l.prefix + "ratelimit:" + key}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 3 {
+		return Result{}, errors.Errorf("ratelimit: unexpected script reply %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      capacity,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Both scripts read the clock of Redis so that replicas with skewed clocks share the
+// same windows, and reply {allowed, remaining, retry after in microseconds}.
+
+// slidingWindowScript keeps the requests of the last window in a sorted set scored by
+// time. ARGV: limit, rate (unused), window in microseconds, unique member.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, math.ceil(window / 1000) + 1)
+	return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// tokenBucketScript refills rate tokens per period up to capacity, lazily on every
+// call. ARGV: capacity, rate, period in microseconds.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[3]) / tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+else
+	tokens = math.min(capacity, tokens + (now - ts) / interval)
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * interval)
+end
+
+redis.call('HSET', key, 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', key, math.ceil(capacity * interval / 1000) + 1)
+return {allowed, math.floor(tokens), retry}
+`)
+
+func newMember() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
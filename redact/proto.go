@@ -0,0 +1,78 @@
+package redact
+
+import (
+	"encoding/base64"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// protoValue converts a message using its JSON field names. Fields declared with
+// [debug_redact = true] and fields on the denylist are redacted.
+func (r *Redactor) protoValue(m protoreflect.Message, depth int) interface{} {
+	if depth > maxDepth || !m.IsValid() {
+		return nil
+	}
+
+	out := map[string]interface{}{}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := fd.JSONName()
+		if r.isSensitiveProtoField(fd) {
+			out[name] = Redacted
+			return true
+		}
+
+		switch {
+		case fd.IsList():
+			list := v.List()
+			items := make([]interface{}, list.Len())
+			for i := range items {
+				items[i] = r.protoScalar(fd, list.Get(i), depth)
+			}
+			out[name] = items
+		case fd.IsMap():
+			entries := map[string]interface{}{}
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				key := k.String()
+				if r.IsSensitiveKey(key) {
+					entries[key] = Redacted
+				} else {
+					entries[key] = r.protoScalar(fd.MapValue(), mv, depth)
+				}
+				return true
+			})
+			out[name] = entries
+		default:
+			out[name] = r.protoScalar(fd, v, depth)
+		}
+		return true
+	})
+
+	return out
+}
+
+func (r *Redactor) protoScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value, depth int) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return r.protoValue(v.Message(), depth+1)
+	case protoreflect.StringKind:
+		return r.String(v.String())
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	default:
+		return v.Interface()
+	}
+}
+
+func (r *Redactor) isSensitiveProtoField(fd protoreflect.FieldDescriptor) bool {
+	if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDebugRedact() {
+		return true
+	}
+
+	return r.IsSensitiveKey(string(fd.Name()))
+}
@@ -0,0 +1,308 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Tag marks a struct field as sensitive, e.g. Password string `redact:"true"`.
+	// Fields tagged `secret:"true"` for the env loader are redacted as well.
+	Tag       = "redact"
+	secretTag = "secret"
+
+	Redacted = "******"
+
+	maxDepth = 32
+)
+
+// DefaultKeys are field and map key names that are always redacted. Names are
+// compared case-insensitively with '_' and '-' removed, so "api_key" also matches
+// "ApiKey" and "api-key".
+var DefaultKeys = []string{
+	"password", "passwd", "pass", "secret", "token", "access_token", "refresh_token",
+	"id_token", "authorization", "cookie", "set_cookie", "api_key", "private_key",
+	"client_secret", "card_number", "cvv", "cvc", "pin", "otp",
+}
+
+type Pattern struct {
+	Regexp *regexp.Regexp
+	// Replace returns the replacement of a match; the match is replaced by Redacted when nil.
+	Replace func(match string) string
+}
+
+// DefaultPatterns scrub bearer and basic credentials, JWTs and card numbers that pass
+// the Luhn check from any string value.
+var DefaultPatterns = []Pattern{
+	{
+		Regexp: regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9\-._~+/]+=*`),
+		Replace: func(match string) string {
+			return strings.SplitN(match, " ", 2)[0] + " " + Redacted
+		},
+	},
+	{Regexp: regexp.MustCompile(`\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]*`)},
+	{
+		Regexp: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Replace: func(match string) string {
+			if !luhn(match) {
+				return match
+			}
+			return Redacted
+		},
+	},
+}
+
+type Options struct {
+	Keys     []string
+	Patterns []Pattern
+}
+
+// Redactor masks sensitive values before they are logged or sent to Sentry.
+type Redactor struct {
+	keys     map[string]struct{}
+	patterns []Pattern
+}
+
+func New(opts Options) *Redactor {
+	r := &Redactor{keys: map[string]struct{}{}, patterns: opts.Patterns}
+	for _, k := range opts.Keys {
+		r.keys[normalize(k)] = struct{}{}
+	}
+
+	return r
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultRedactor = New(Options{Keys: DefaultKeys, Patterns: DefaultPatterns})
+)
+
+func Default() *Redactor {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultRedactor
+}
+
+func SetDefault(r *Redactor) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRedactor = r
+}
+
+func Value(v interface{}) interface{} {
+	return Default().Value(v)
+}
+
+func String(s string) string {
+	return Default().String(s)
+}
+
+// IsSensitiveKey reports whether a field or map key is on the denylist.
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	_, ok := r.keys[normalize(key)]
+	return ok
+}
+
+// String scrubs the patterns from s.
+func (r *Redactor) String(s string) string {
+	for _, p := range r.patterns {
+		if p.Replace == nil {
+			s = p.Regexp.ReplaceAllString(s, Redacted)
+			continue
+		}
+		s = p.Regexp.ReplaceAllStringFunc(s, p.Replace)
+	}
+
+	return s
+}
+
+// Value returns a redacted copy of v built from maps, slices and scalars, suitable for
+// JSON encoding. Struct fields are keyed by their json name. v itself is never modified.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if m, ok := v.(proto.Message); ok {
+		return r.protoValue(m.ProtoReflect(), 0)
+	}
+
+	return r.value(reflect.ValueOf(v), 0)
+}
+
+func (r *Redactor) value(rv reflect.Value, depth int) interface{} {
+	if depth > maxDepth {
+		return nil
+	}
+
+	if rv.IsValid() && rv.CanInterface() {
+		switch v := rv.Interface().(type) {
+		case proto.Message:
+			if rv.Kind() == reflect.Ptr && rv.IsNil() {
+				return nil
+			}
+			return r.protoValue(v.ProtoReflect(), depth)
+		case time.Time:
+			return v
+		case time.Duration:
+			return v.String()
+		case json.RawMessage:
+			return r.jsonValue(v)
+		case []byte:
+			return r.String(string(v))
+		case error:
+			if rv.Kind() != reflect.Struct {
+				return r.String(v.Error())
+			}
+		case fmt.Stringer:
+			if rv.Kind() != reflect.Struct && rv.Kind() != reflect.Ptr {
+				return r.String(v.String())
+			}
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return r.value(rv.Elem(), depth+1)
+	case reflect.String:
+		return r.String(rv.String())
+	case reflect.Struct:
+		return r.structValue(rv, depth)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if r.IsSensitiveKey(key) {
+				out[key] = Redacted
+				continue
+			}
+			out[key] = r.value(iter.Value(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = r.value(rv.Index(i), depth+1)
+		}
+		return out
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	default:
+		if rv.CanInterface() {
+			return rv.Interface()
+		}
+		return nil
+	}
+}
+
+func (r *Redactor) structValue(rv reflect.Value, depth int) interface{} {
+	rt := rv.Type()
+	out := make(map[string]interface{}, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+		if r.isSensitiveField(field, name) {
+			if !rv.Field(i).IsZero() {
+				out[name] = Redacted
+			}
+			continue
+		}
+		out[name] = r.value(rv.Field(i), depth+1)
+	}
+
+	return out
+}
+
+func (r *Redactor) isSensitiveField(field reflect.StructField, name string) bool {
+	for _, tag := range []string{Tag, secretTag} {
+		if ok, _ := strconv.ParseBool(field.Tag.Get(tag)); ok {
+			return true
+		}
+	}
+
+	return r.IsSensitiveKey(field.Name) || r.IsSensitiveKey(name)
+}
+
+// JSON redacts a JSON document, e.g. a request body. Invalid JSON is scrubbed as text.
+func (r *Redactor) JSON(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []byte(r.String(string(body)))
+	}
+
+	out, err := json.Marshal(r.value(reflect.ValueOf(v), 0))
+	if err != nil {
+		return []byte(r.String(string(body)))
+	}
+
+	return out
+}
+
+func (r *Redactor) jsonValue(raw json.RawMessage) interface{} {
+	return json.RawMessage(r.JSON(raw))
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, false
+	}
+
+	return field.Name, false
+}
+
+func normalize(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "").Replace(key))
+}
+
+func luhn(number string) bool {
+	sum, double, digits := 0, false, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
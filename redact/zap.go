@@ -0,0 +1,73 @@
+package redact
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Any is zap.Any with v redacted by the default Redactor.
+func Any(key string, v interface{}) zap.Field {
+	return zap.Any(key, Value(v))
+}
+
+// Field redacts a single zap field: denylisted keys are masked, strings are scrubbed
+// and reflected values are replaced by their redacted copy.
+func (r *Redactor) Field(f zapcore.Field) zapcore.Field {
+	if r.IsSensitiveKey(f.Key) {
+		return zap.String(f.Key, Redacted)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.String(f.String)
+	case zapcore.ByteStringType:
+		f.Interface = []byte(r.String(string(f.Interface.([]byte))))
+	case zapcore.ReflectType:
+		return zap.Reflect(f.Key, r.Value(f.Interface))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			if scrubbed := r.String(err.Error()); scrubbed != err.Error() {
+				return zap.String(f.Key, scrubbed)
+			}
+		}
+	}
+
+	return f
+}
+
+// NewCore redacts the fields and message of every entry written to core.
+func NewCore(core zapcore.Core, r *Redactor) zapcore.Core {
+	return &redactCore{Core: core, redactor: r}
+}
+
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func (rc *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: rc.Core.With(rc.fields(fields)), redactor: rc.redactor}
+}
+
+func (rc *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if rc.Enabled(ent.Level) {
+		return ce.AddCore(ent, rc)
+	}
+
+	return ce
+}
+
+func (rc *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = rc.redactor.String(ent.Message)
+
+	return rc.Core.Write(ent, rc.fields(fields))
+}
+
+func (rc *redactCore) fields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = rc.redactor.Field(f)
+	}
+
+	return out
+}
@@ -1,45 +1,122 @@
 package redis
 
 import (
+	"context"
+	"crypto/tls"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+
+	"github.com/diki-haryadi/ztools/constant"
 )
 
+// Config selects the client by Mode: standalone uses the first of Addrs, sentinel
+// the Sentinel addresses and MasterName, cluster the seed nodes of Addrs. Zero
+// durations and sizes take the defaults below, except MaxRetries where zero disables
+// the retries.
 type Config struct {
-	Addr     string `mapstructure:"addr"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"poolSize"`
+	Mode             string        `mapstructure:"mode"`
+	Addr             string        `mapstructure:"addr"`
+	Addrs            []string      `mapstructure:"addrs"`
+	MasterName       string        `mapstructure:"masterName"`
+	Username         string        `mapstructure:"username"`
+	Password         string        `mapstructure:"password"`
+	SentinelPassword string        `mapstructure:"sentinelPassword"`
+	DB               int           `mapstructure:"db"`
+	TLS              bool          `mapstructure:"tls"`
+	PoolSize         int           `mapstructure:"poolSize"`
+	MinIdleConns     int           `mapstructure:"minIdleConns"`
+	MaxRetries       int           `mapstructure:"maxRetries"`
+	MinRetryBackoff  time.Duration `mapstructure:"minRetryBackoff"`
+	MaxRetryBackoff  time.Duration `mapstructure:"maxRetryBackoff"`
+	DialTimeout      time.Duration `mapstructure:"dialTimeout"`
+	ReadTimeout      time.Duration `mapstructure:"readTimeout"`
+	WriteTimeout     time.Duration `mapstructure:"writeTimeout"`
+	PoolTimeout      time.Duration `mapstructure:"poolTimeout"`
+	IdleTimeout      time.Duration `mapstructure:"idleTimeout"`
 }
 
 const (
-	maxRetries      = 5
 	minRetryBackoff = 300 * time.Millisecond
 	maxRetryBackoff = 500 * time.Millisecond
 	dialTimeout     = 5 * time.Second
 	readTimeout     = 5 * time.Second
 	writeTimeout    = 3 * time.Second
-	minIdleConns    = 20
+	minIdleConns    = 5
 	poolTimeout     = 6 * time.Second
 	idleTimeout     = 12 * time.Second
 )
 
 func NewUniversalRedisClient(cfg *Config) *redis.UniversalClient {
-	universalClient := redis.NewUniversalClient(&redis.UniversalOptions{
-		Addrs:           []string{cfg.Addr},
-		Password:        cfg.Password, // no password set
-		DB:              cfg.DB,       // use defaultLogger DB
-		MaxRetries:      maxRetries,
-		MinRetryBackoff: minRetryBackoff,
-		MaxRetryBackoff: maxRetryBackoff,
-		DialTimeout:     dialTimeout,
-		ReadTimeout:     readTimeout,
-		WriteTimeout:    writeTimeout,
-		PoolSize:        cfg.PoolSize,
-		MinIdleConns:    minIdleConns,
-		PoolTimeout:     poolTimeout,
-		IdleTimeout:     idleTimeout,
-	})
+	opts := universalOptions(cfg)
+
+	var universalClient redis.UniversalClient
+	switch cfg.Mode {
+	case constant.RedisModeSentinel:
+		universalClient = redis.NewFailoverClient(opts.Failover())
+	case constant.RedisModeCluster:
+		universalClient = redis.NewClusterClient(opts.Cluster())
+	case constant.RedisModeStandalone:
+		universalClient = redis.NewClient(opts.Simple())
+	default:
+		universalClient = redis.NewUniversalClient(opts)
+	}
 	return &universalClient
 }
+
+// Ping checks the connection, e.g. on startup, within timeout.
+func Ping(ctx context.Context, client redis.UniversalClient, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return client.Ping(ctx).Err()
+}
+
+func universalOptions(cfg *Config) *redis.UniversalOptions {
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{cfg.Addr}
+	}
+
+	// go-redis reads 0 as its default of 3 retries and -1 as none.
+	retries := cfg.MaxRetries
+	if retries == 0 {
+		retries = -1
+	}
+	idleConns := orDefault(cfg.MinIdleConns, minIdleConns)
+	if cfg.PoolSize > 0 && idleConns > cfg.PoolSize {
+		idleConns = cfg.PoolSize
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       cfg.MasterName,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelPassword: cfg.SentinelPassword,
+		DB:               cfg.DB,
+		MaxRetries:       retries,
+		MinRetryBackoff:  orDefault(cfg.MinRetryBackoff, minRetryBackoff),
+		MaxRetryBackoff:  orDefault(cfg.MaxRetryBackoff, maxRetryBackoff),
+		DialTimeout:      orDefault(cfg.DialTimeout, dialTimeout),
+		ReadTimeout:      orDefault(cfg.ReadTimeout, readTimeout),
+		WriteTimeout:     orDefault(cfg.WriteTimeout, writeTimeout),
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     idleConns,
+		PoolTimeout:      orDefault(cfg.PoolTimeout, poolTimeout),
+		IdleTimeout:      orDefault(cfg.IdleTimeout, idleTimeout),
+	}
+	if cfg.TLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return opts
+}
+
+func orDefault[T int | time.Duration](v T, def T) T {
+	if v == 0 {
+		return def
+	}
+
+	return v
+}
@@ -5,12 +5,32 @@ import (
 	"time"
 
 	"github.com/getsentry/sentry-go"
+
+	"github.com/diki-haryadi/ztools/redact"
 )
 
 type Options struct {
 	Repanic         bool
 	WaitForDelivery bool
 	Timeout         time.Duration
+	// Tags are set on the scope of every captured event, e.g. application and AppEnv.
+	Tags map[string]string
+}
+
+func AppTags(appName string, appEnv string) map[string]string {
+	return map[string]string{
+		"application": appName,
+		"AppEnv":      appEnv,
+	}
+}
+
+func SetTags(hub *sentry.Hub, options *Options) {
+	if options == nil {
+		return
+	}
+	for k, v := range options.Tags {
+		hub.Scope().SetTag(k, v)
+	}
 }
 
 func RecoverWithSentry(hub *sentry.Hub, ctx context.Context, options *Options) {
@@ -25,3 +45,54 @@ func RecoverWithSentry(hub *sentry.Hub, ctx context.Context, options *Options) {
 		}
 	}
 }
+
+// RedactEvent is a sentry.ClientOptions.BeforeSend that masks sensitive extras,
+// contexts, request headers, cookies and bodies, and breadcrumb data.
+func RedactEvent(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	r := redact.Default()
+
+	event.Message = r.String(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = r.String(event.Exception[i].Value)
+	}
+	for k, v := range event.Extra {
+		event.Extra[k] = redactEntry(r, k, v)
+	}
+	for name, ctx := range event.Contexts {
+		for k, v := range ctx {
+			ctx[k] = redactEntry(r, k, v)
+		}
+		event.Contexts[name] = ctx
+	}
+	for _, b := range event.Breadcrumbs {
+		b.Message = r.String(b.Message)
+		for k, v := range b.Data {
+			b.Data[k] = redactEntry(r, k, v)
+		}
+	}
+	if req := event.Request; req != nil {
+		req.URL = r.String(req.URL)
+		req.QueryString = r.String(req.QueryString)
+		req.Data = string(r.JSON([]byte(req.Data)))
+		if req.Cookies != "" {
+			req.Cookies = redact.Redacted
+		}
+		for k, v := range req.Headers {
+			if r.IsSensitiveKey(k) {
+				req.Headers[k] = redact.Redacted
+			} else {
+				req.Headers[k] = r.String(v)
+			}
+		}
+	}
+
+	return event
+}
+
+func redactEntry(r *redact.Redactor, key string, v interface{}) interface{} {
+	if r.IsSensitiveKey(key) {
+		return redact.Redacted
+	}
+
+	return r.Value(v)
+}
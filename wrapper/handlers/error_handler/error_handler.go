@@ -42,7 +42,7 @@ var ErrorHandler = func(f wrapper.HandlerFunc) wrapper.HandlerFunc {
 				hub.CaptureException(err)
 			}
 
-			logger.Zap.Error(
+			logger.FromContext(ctx).Error(
 				err.Error(),
 				logFields...,
 			)
@@ -15,10 +15,10 @@ var RecoveryHandler = func(f wrapper.HandlerFunc) wrapper.HandlerFunc {
 			if r := recover(); r != nil {
 				err, ok := r.(error)
 				if !ok {
-					logger.Zap.Sugar().Errorf("%v", r)
+					logger.FromContext(ctx).Sugar().Errorf("%v", r)
 					return
 				}
-				logger.Zap.Error(err.Error(), zap.Error(err))
+				logger.FromContext(ctx).Error(err.Error(), zap.Error(err))
 			}
 		}()
 
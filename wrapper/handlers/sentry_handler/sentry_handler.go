@@ -6,26 +6,38 @@ import (
 	"github.com/getsentry/sentry-go"
 
 	"github.com/diki-haryadi/ztools/config"
+	"github.com/diki-haryadi/ztools/redact"
 	sentryUtils "github.com/diki-haryadi/ztools/sentry/sentry_utils"
 	"github.com/diki-haryadi/ztools/wrapper"
 )
 
+// SentryHandler tags events with config.Current when it is loaded. Use
+// NewSentryHandler to pass the options explicitly.
 var SentryHandler = func(f wrapper.HandlerFunc) wrapper.HandlerFunc {
-	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
-		hub := sentry.GetHubFromContext(ctx)
-		if hub == nil {
-			hub = sentry.CurrentHub().Clone()
-			ctx = sentry.SetHubOnContext(ctx, hub)
-		}
-		hub.Scope().SetExtra("args", args)
-		hub.Scope().SetTag("application", config.BaseConfig.App.AppName)
-		hub.Scope().SetTag("AppEnv", config.BaseConfig.App.AppEnv)
+	opts := &sentryUtils.Options{
+		Repanic: true,
+	}
+	if cfg := config.Current(); cfg != nil {
+		opts.Tags = sentryUtils.AppTags(cfg.App.AppName, cfg.App.AppEnv)
+	}
 
-		opts := &sentryUtils.Options{
-			Repanic: true,
-		}
-		defer sentryUtils.RecoverWithSentry(hub, ctx, opts)
+	return NewSentryHandler(opts)(f)
+}
+
+func NewSentryHandler(opts *sentryUtils.Options) func(f wrapper.HandlerFunc) wrapper.HandlerFunc {
+	return func(f wrapper.HandlerFunc) wrapper.HandlerFunc {
+		return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			hub := sentry.GetHubFromContext(ctx)
+			if hub == nil {
+				hub = sentry.CurrentHub().Clone()
+				ctx = sentry.SetHubOnContext(ctx, hub)
+			}
+			hub.Scope().SetExtra("args", redact.Value(args))
+			sentryUtils.SetTags(hub, opts)
 
-		return f(ctx, args)
+			defer sentryUtils.RecoverWithSentry(hub, ctx, opts)
+
+			return f(ctx, args)
+		}
 	}
 }
@@ -4,10 +4,11 @@ import (
 	"golang.org/x/net/context"
 )
 
-type middleware func(HandlerFunc) HandlerFunc
+// Middleware wraps a HandlerFunc, e.g. the recovery, sentry and error handlers.
+type Middleware func(HandlerFunc) HandlerFunc
 type HandlerFunc func(ctx context.Context, args ...interface{}) (interface{}, error)
 
-func BuildChain(f HandlerFunc, m ...middleware) HandlerFunc {
+func BuildChain(f HandlerFunc, m ...Middleware) HandlerFunc {
 	if len(m) == 0 {
 		return f
 	}